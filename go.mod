@@ -3,15 +3,19 @@ module github.com/grinderz/go-libs
 go 1.25.0
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/grinderz/gocpio v1.0.2-0.20200707140622-b5c6fe3526ec
+	github.com/ulikunitz/xz v0.5.17
 	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8
+	go.opentelemetry.io/otel/trace v1.43.0
 	go.uber.org/automaxprocs v1.6.0
 	go.uber.org/zap v1.27.1
+	golang.org/x/sys v0.42.0
 	golang.org/x/tools v0.43.0
 )
 
 require (
-	github.com/stretchr/testify v1.11.1 // indirect
+	go.opentelemetry.io/otel v1.43.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/mod v0.34.0 // indirect
 	golang.org/x/sync v0.20.0 // indirect
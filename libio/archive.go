@@ -0,0 +1,23 @@
+package libio
+
+import (
+	"io"
+	"io/fs"
+	"time"
+)
+
+// ArchiveMember describes a tar or zip member.
+type ArchiveMember struct {
+	Name    string
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+}
+
+// ArchiveWalkFunc is called for every member in archive order, data reads the member contents
+// and is only valid until the function returns.
+type ArchiveWalkFunc func(member *ArchiveMember, data io.Reader) error
+
+// RewriteFunc is called with the contents of every regular member, it returns the new contents
+// or nil to keep the member untouched.
+type RewriteFunc func(member *ArchiveMember, data []byte) ([]byte, error)
@@ -0,0 +1,136 @@
+package libio_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestRewriteTar(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1_600_000_000, 0)
+
+	var archive bytes.Buffer
+
+	tarWriter := tar.NewWriter(&archive)
+	checkError(t, tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "lib/", Mode: 0o755, ModTime: modTime}))
+	writeTarFile(t, tarWriter, "lib/firmware.bin", "firmware", modTime)
+	checkError(t, tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "fw", Linkname: "lib/firmware.bin"}))
+	writeTarFile(t, tarWriter, "README", "readme", modTime)
+	checkError(t, tarWriter.Close())
+
+	for name, input := range map[string][]byte{"tar": archive.Bytes(), "tar.gz": packGZ(t, archive.Bytes())} {
+		var rewritten bytes.Buffer
+
+		count, err := libio.RewriteTar(&rewritten, bytes.NewReader(input), upperFirmware, 1<<20)
+		checkError(t, err)
+
+		if count != 1 || bytes.HasPrefix(rewritten.Bytes(), []byte{0x1F, 0x8B}) != (name == "tar.gz") {
+			t.Fatalf("%s rewritten %d", name, count)
+		}
+
+		var members []string
+
+		checkError(t, libio.WalkTar(&rewritten, func(member *libio.ArchiveMember, data io.Reader) error {
+			content, err := io.ReadAll(data)
+			members = append(members, member.Name+"="+string(content))
+
+			if member.Mode.IsRegular() && !member.ModTime.Equal(modTime) {
+				t.Fatalf("%s %s mod time: %s", name, member.Name, member.ModTime)
+			}
+
+			return err
+		}, 1<<20))
+
+		if strings.Join(members, ",") != "lib/=,lib/firmware.bin=FIRMWARE,fw=,README=readme" {
+			t.Fatalf("%s members: %v", name, members)
+		}
+	}
+}
+
+func TestRewriteZip(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+
+	zipWriter := zip.NewWriter(&archive)
+	checkError(t, zipWriter.SetComment("bundle"))
+
+	for _, header := range []*zip.FileHeader{
+		{Name: "firmware.bin", Method: zip.Store},
+		{Name: "README", Method: zip.Deflate},
+	} {
+		memberWriter, err := zipWriter.CreateHeader(header)
+		checkError(t, err)
+
+		_, err = memberWriter.Write([]byte(strings.ToLower(header.Name)))
+		checkError(t, err)
+	}
+
+	checkError(t, zipWriter.Close())
+
+	var rewritten bytes.Buffer
+
+	count, err := libio.RewriteZip(&rewritten, bytes.NewReader(archive.Bytes()), int64(archive.Len()), upperFirmware, 0)
+	checkError(t, err)
+
+	zipReader, err := zip.NewReader(bytes.NewReader(rewritten.Bytes()), int64(rewritten.Len()))
+	checkError(t, err)
+
+	if count != 1 || zipReader.Comment != "bundle" || len(zipReader.File) != 2 || zipReader.File[0].Method != zip.Store {
+		t.Fatalf("rewritten %d: %+v", count, zipReader)
+	}
+
+	var contents []string
+
+	checkError(t, libio.WalkZip(bytes.NewReader(rewritten.Bytes()), int64(rewritten.Len()),
+		func(_ *libio.ArchiveMember, data io.Reader) error {
+			content, err := io.ReadAll(data)
+			contents = append(contents, string(content))
+
+			return err
+		}, 0))
+
+	if strings.Join(contents, ",") != "FIRMWARE.BIN,readme" {
+		t.Fatalf("contents: %v", contents)
+	}
+
+	err = libio.WalkZip(bytes.NewReader(rewritten.Bytes()), int64(rewritten.Len()),
+		func(_ *libio.ArchiveMember, data io.Reader) error {
+			_, err := io.Copy(io.Discard, data)
+			return err
+		}, 15)
+	if !libio.IsUnpackMaxDecompressLimitReachedError(err) {
+		t.Fatalf("decompress limit not enforced: %v", err)
+	}
+}
+
+func upperFirmware(member *libio.ArchiveMember, data []byte) ([]byte, error) {
+	if !strings.HasPrefix(member.Name, "lib/") && !strings.HasPrefix(member.Name, "firmware") {
+		return nil, nil
+	}
+
+	return bytes.ToUpper(data), nil
+}
+
+func writeTarFile(t *testing.T, tarWriter *tar.Writer, name, data string, modTime time.Time) {
+	t.Helper()
+
+	checkError(t, tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}))
+
+	_, err := tarWriter.Write([]byte(data))
+	checkError(t, err)
+}
@@ -0,0 +1,166 @@
+package libio
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/grinderz/go-libs/libos"
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+const defaultFilePerm = 0o644
+
+var ErrAtomicFileClosed = errors.New("atomic file closed")
+
+// AtomicFileOptions control the permissions of an AtomicFile. Perm sets the mode of the committed file,
+// 0 keeps the mode of the file being replaced and creates new files with 0644. PreserveMode keeps the
+// mode of the file being replaced even when Perm is set, PreserveOwner keeps its owner.
+type AtomicFileOptions struct {
+	Perm          fs.FileMode
+	PreserveMode  bool
+	PreserveOwner bool
+}
+
+// AtomicFile writes a temporary file next to the destination and renames it over the destination
+// on Commit, readers never see a partial file. Close without Commit removes the temporary file.
+type AtomicFile struct {
+	*os.File
+
+	path      string
+	perm      fs.FileMode
+	uid       int
+	gid       int
+	committed bool
+	closed    bool
+}
+
+func CreateAtomicFile(path string, opts *AtomicFileOptions) (*AtomicFile, error) {
+	if opts == nil {
+		opts = &AtomicFileOptions{Perm: 0, PreserveMode: false, PreserveOwner: false}
+	}
+
+	atomicFile := &AtomicFile{
+		File:      nil,
+		path:      path,
+		perm:      opts.Perm,
+		uid:       -1,
+		gid:       -1,
+		committed: false,
+		closed:    false,
+	}
+
+	if atomicFile.perm == 0 {
+		atomicFile.perm = defaultFilePerm
+	}
+
+	stat, exists, err := libos.IsExists(path)
+	if err != nil {
+		return nil, zerr.Wrap(fmt.Errorf("stat: %w", err), zap.String("path", path))
+	}
+
+	if exists && (opts.PreserveMode || opts.Perm == 0) {
+		atomicFile.perm = stat.Mode().Perm()
+	}
+
+	if exists && opts.PreserveOwner {
+		atomicFile.uid, atomicFile.gid = fileOwner(stat)
+	}
+
+	if atomicFile.File, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp"); err != nil {
+		return nil, zerr.Wrap(fmt.Errorf("create temp: %w", err), zap.String("path", path))
+	}
+
+	return atomicFile, nil
+}
+
+// Commit syncs the temporary file, applies the permissions, renames it to the destination
+// and syncs the directory.
+func (f *AtomicFile) Commit() error {
+	if f.committed || f.closed {
+		return ErrAtomicFileClosed
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync temp: %w", err)
+	}
+
+	if err := f.Chmod(f.perm); err != nil {
+		return fmt.Errorf("chmod temp: %w", err)
+	}
+
+	if f.uid >= 0 || f.gid >= 0 {
+		if err := f.Chown(f.uid, f.gid); err != nil {
+			return fmt.Errorf("chown temp: %w", err)
+		}
+	}
+
+	if err := f.File.Close(); err != nil {
+		return fmt.Errorf("close temp: %w", err)
+	}
+
+	f.closed = true
+
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		f.remove()
+		return fmt.Errorf("rename temp: %w", err)
+	}
+
+	f.committed = true
+
+	if err := syncDir(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+
+	return nil
+}
+
+// Close discards the temporary file unless the file was committed.
+func (f *AtomicFile) Close() error {
+	if f.committed || f.closed {
+		return nil
+	}
+
+	f.closed = true
+
+	err := f.File.Close()
+
+	f.remove()
+
+	if err != nil {
+		return fmt.Errorf("close temp: %w", err)
+	}
+
+	return nil
+}
+
+// Path returns the destination path.
+func (f *AtomicFile) Path() string {
+	return f.path
+}
+
+func (f *AtomicFile) remove() {
+	if err := os.Remove(f.Name()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		zerr.Wrap(err).WithField(
+			zap.String("temp_path", f.Name()),
+		).LogError(libzap.Logger(), "temp file remove failed")
+	}
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err := dir.Sync(); err != nil && !isSyncUnsupported(err) {
+		_ = dir.Close()
+		return err //nolint:wrapcheck
+	}
+
+	return dir.Close() //nolint:wrapcheck
+}
@@ -0,0 +1,10 @@
+package libio
+
+import (
+	"errors"
+	"syscall"
+)
+
+func isSyncUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL)
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package libio
+
+import (
+	"io/fs"
+)
+
+func fileOwner(_ fs.FileInfo) (int, int) {
+	return -1, -1
+}
+
+// isSyncUnsupported accepts every error, directories cannot be synced on Windows.
+func isSyncUnsupported(_ error) bool {
+	return true
+}
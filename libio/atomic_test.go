@@ -0,0 +1,100 @@
+package libio_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestCloneReaderFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "image")
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(io.ErrUnexpectedEOF))
+
+	if err := libio.CloneReader(failing, dst); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("clone reader error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	checkError(t, err)
+
+	if len(entries) != 0 {
+		t.Fatalf("leftover files: %v", entries)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+
+	checkError(t, os.WriteFile(src, []byte("firmware"), 0o600))
+	checkError(t, os.WriteFile(dst, []byte("old"), 0o644))
+	checkError(t, libio.CopyFile(src, dst))
+
+	checkFile(t, dst, "firmware", 0o600)
+}
+
+func TestAtomicFilePreserveMode(t *testing.T) {
+	t.Parallel()
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	checkError(t, os.WriteFile(dst, []byte("old"), 0o640))
+
+	atomicFile, err := libio.CreateAtomicFile(dst, &libio.AtomicFileOptions{
+		Perm:          0o600,
+		PreserveMode:  true,
+		PreserveOwner: true,
+	})
+	checkError(t, err)
+
+	defer atomicFile.Close()
+
+	_, err = atomicFile.WriteString("new")
+	checkError(t, err)
+
+	checkFile(t, dst, "old", 0o640)
+	checkError(t, atomicFile.Commit())
+	checkFile(t, dst, "new", 0o640)
+
+	if err := atomicFile.Commit(); !errors.Is(err, libio.ErrAtomicFileClosed) {
+		t.Fatalf("second commit: %v", err)
+	}
+}
+
+func TestCloneReaderMode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	existing, created := filepath.Join(dir, "existing"), filepath.Join(dir, "created")
+	checkError(t, os.WriteFile(existing, []byte("old"), 0o600))
+
+	checkError(t, libio.CloneReader(strings.NewReader("new"), existing))
+	checkError(t, libio.CloneReader(strings.NewReader("new"), created))
+
+	checkFile(t, existing, "new", 0o600)
+	checkFile(t, created, "new", 0o644)
+}
+
+func checkFile(t *testing.T, path, data string, perm os.FileMode) {
+	t.Helper()
+
+	stat, err := os.Stat(path)
+	checkError(t, err)
+
+	content, err := os.ReadFile(path)
+	checkError(t, err)
+
+	if string(content) != data || stat.Mode().Perm() != perm {
+		t.Fatalf("%s: %q %o", path, content, stat.Mode().Perm())
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix && !linux
+
+package libio
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isSyncUnsupported matches the errors of filesystems that cannot fsync a directory,
+// I/O errors are reported.
+func isSyncUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EBADF)
+}
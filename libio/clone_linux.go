@@ -0,0 +1,125 @@
+package libio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const copyFileRangeChunk = 1 << 30
+
+// cloneFileData clones src into the empty dst trying a reflink, copy_file_range for dense files,
+// a sparse copy and finally a plain copy. A method falls through only when it is
+// not supported for the pair of files and nothing was written yet.
+func cloneFileData(dst, src *os.File, size int64) (CloneMethodEnum, error) {
+	if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err == nil {
+		return CloneMethodReflink, nil
+	} else if !isCloneUnsupported(err) {
+		return CloneMethodReflink, fmt.Errorf("ficlone: %w", err)
+	}
+
+	// copy_file_range allocates the holes on most filesystems, sparse files go to the sparse copy
+	if !hasHoles(src, size) {
+		if copied, err := copyFileRange(dst, src, size); err == nil {
+			return CloneMethodCopyFileRange, nil
+		} else if copied > 0 || !isCloneUnsupported(err) {
+			return CloneMethodCopyFileRange, fmt.Errorf("copy_file_range: %w", err)
+		}
+	}
+
+	if copied, err := copySparse(dst, src, size); err == nil {
+		return CloneMethodSparse, nil
+	} else if copied > 0 || !isCloneUnsupported(err) {
+		return CloneMethodSparse, fmt.Errorf("sparse copy: %w", err)
+	}
+
+	if _, err := io.Copy(dst, io.NewSectionReader(src, 0, size)); err != nil {
+		return CloneMethodCopy, fmt.Errorf("copy: %w", err)
+	}
+
+	return CloneMethodCopy, nil
+}
+
+func copyFileRange(dst, src *os.File, size int64) (int64, error) {
+	var srcOffset, dstOffset, copied int64
+
+	for copied < size {
+		n, err := unix.CopyFileRange(
+			int(src.Fd()), &srcOffset, int(dst.Fd()), &dstOffset, int(min(size-copied, copyFileRangeChunk)), 0,
+		)
+		if err != nil {
+			return copied, err //nolint:wrapcheck
+		}
+
+		if n == 0 {
+			return copied, io.ErrUnexpectedEOF
+		}
+
+		copied += int64(n)
+	}
+
+	return copied, nil
+}
+
+// hasHoles reports whether SEEK_HOLE finds a hole before the end of src, files of filesystems
+// without SEEK_HOLE support are dense.
+func hasHoles(src *os.File, size int64) bool {
+	hole, err := unix.Seek(int(src.Fd()), 0, unix.SEEK_HOLE)
+
+	return err == nil && hole < size
+}
+
+// copySparse copies the data ranges reported by SEEK_DATA/SEEK_HOLE and leaves the holes unallocated.
+func copySparse(dst, src *os.File, size int64) (int64, error) {
+	var copied int64
+
+	for offset := int64(0); offset < size; {
+		dataStart, err := unix.Seek(int(src.Fd()), offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break
+		}
+
+		if err != nil {
+			return copied, err //nolint:wrapcheck
+		}
+
+		dataEnd, err := unix.Seek(int(src.Fd()), dataStart, unix.SEEK_HOLE)
+		if err != nil {
+			return copied, err //nolint:wrapcheck
+		}
+
+		dataEnd = min(dataEnd, size)
+
+		n, err := io.Copy(
+			io.NewOffsetWriter(dst, dataStart),
+			io.NewSectionReader(src, dataStart, dataEnd-dataStart),
+		)
+		copied += n
+
+		if err != nil {
+			return copied, err //nolint:wrapcheck
+		}
+
+		offset = dataEnd
+	}
+
+	if err := dst.Truncate(size); err != nil {
+		return copied, err //nolint:wrapcheck
+	}
+
+	return copied, nil
+}
+
+func isCloneUnsupported(err error) bool {
+	return errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.ENOTSUP) ||
+		errors.Is(err, unix.EINVAL) ||
+		errors.Is(err, unix.ENOSYS) ||
+		errors.Is(err, unix.ENOTTY) ||
+		errors.Is(err, unix.EBADF) ||
+		errors.Is(err, unix.EPERM)
+}
@@ -0,0 +1,59 @@
+package libio_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestCloneFileSparse(t *testing.T) {
+	t.Parallel()
+
+	const size = 64 << 20
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "image"), filepath.Join(dir, "image.bak")
+
+	srcFile, err := os.OpenFile(src, os.O_CREATE|os.O_WRONLY, 0o600)
+	checkError(t, err)
+
+	_, err = srcFile.WriteString("head")
+	checkError(t, err)
+
+	_, err = srcFile.WriteAt([]byte("tail"), size-4)
+	checkError(t, err)
+	checkError(t, srcFile.Close())
+
+	if allocatedBytes(t, src) >= size {
+		t.Skip("the filesystem does not keep holes")
+	}
+
+	method, err := libio.CloneFile(src, dst)
+	checkError(t, err)
+
+	if method == libio.CloneMethodCopyFileRange || method == libio.CloneMethodCopy {
+		t.Fatalf("clone method: %s", method)
+	}
+
+	if allocated := allocatedBytes(t, dst); allocated >= size/2 {
+		t.Fatalf("%s allocated %d bytes of %d", method, allocated, size)
+	}
+}
+
+func allocatedBytes(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	checkError(t, err)
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Fatalf("stat %s: %T", path, info.Sys())
+	}
+
+	// st_blocks counts 512 byte units
+	return stat.Blocks * 512
+}
@@ -0,0 +1,56 @@
+package libio
+
+import (
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=CloneMethodEnum -linecomment -output clone_method_enum_string.go
+type CloneMethodEnum int //nolint:recvcheck
+
+const (
+	CloneMethodUnknown       CloneMethodEnum = iota // unknown
+	CloneMethodReflink       CloneMethodEnum = iota // reflink
+	CloneMethodCopyFileRange CloneMethodEnum = iota // copy_file_range
+	CloneMethodSparse        CloneMethodEnum = iota // sparse
+	CloneMethodCopy          CloneMethodEnum = iota // copy
+)
+
+func (e *CloneMethodEnum) SetValue(value string) error {
+	method := CloneMethodFromString(value)
+	if method == CloneMethodUnknown {
+		return liberrors.NewInvalidStringEntityError("clone_method", value)
+	}
+
+	*e = method
+
+	return nil
+}
+
+func (e CloneMethodEnum) MarshalText() ([]byte, error) {
+	if e == CloneMethodUnknown {
+		return nil, liberrors.NewInvalidStringEntityError("clone_method", e.String())
+	}
+
+	return []byte(e.String()), nil
+}
+
+func (e *CloneMethodEnum) UnmarshalText(text []byte) error {
+	return e.SetValue(string(text))
+}
+
+func CloneMethodFromString(value string) CloneMethodEnum {
+	switch strings.ToLower(value) {
+	case "reflink":
+		return CloneMethodReflink
+	case "copy_file_range":
+		return CloneMethodCopyFileRange
+	case "sparse":
+		return CloneMethodSparse
+	case "copy":
+		return CloneMethodCopy
+	default:
+		return CloneMethodUnknown
+	}
+}
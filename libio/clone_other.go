@@ -0,0 +1,17 @@
+//go:build !linux
+
+package libio
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func cloneFileData(dst, src *os.File, size int64) (CloneMethodEnum, error) {
+	if _, err := io.Copy(dst, io.NewSectionReader(src, 0, size)); err != nil {
+		return CloneMethodCopy, fmt.Errorf("copy: %w", err)
+	}
+
+	return CloneMethodCopy, nil
+}
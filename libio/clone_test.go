@@ -0,0 +1,44 @@
+package libio_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestCloneFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "image"), filepath.Join(dir, "image.bak")
+
+	srcFile, err := os.OpenFile(src, os.O_CREATE|os.O_WRONLY, 0o600)
+	checkError(t, err)
+
+	_, err = srcFile.WriteString("head")
+	checkError(t, err)
+
+	_, err = srcFile.WriteAt([]byte("tail"), 4<<20)
+	checkError(t, err)
+	checkError(t, srcFile.Close())
+
+	method, err := libio.CloneFile(src, dst)
+	checkError(t, err)
+
+	if runtime.GOOS == "linux" && method == libio.CloneMethodCopy {
+		t.Fatalf("clone method: %s", method)
+	}
+
+	srcData, err := os.ReadFile(src)
+	checkError(t, err)
+
+	checkFile(t, dst, string(srcData), 0o600)
+
+	if !bytes.HasSuffix(srcData, []byte("tail")) {
+		t.Fatal("source data")
+	}
+}
@@ -0,0 +1,197 @@
+package libio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/grinderz/go-libs/liberrors"
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+// DefaultLevel selects the default compression level of a codec.
+const DefaultLevel = -1
+
+var ErrCodecAlreadyRegistered = errors.New("codec already registered")
+
+// Codec is a compression format, decompression limits are enforced by Unpack around NewReader.
+type Codec interface {
+	Name() string
+	Magic() []byte
+	NewReader(reader io.Reader) (io.ReadCloser, error)
+	NewWriter(writer io.Writer, level int) (io.WriteCloser, error)
+}
+
+var (
+	codecs   = []Codec{&gzCodec{}, &xzCodec{}} //nolint:gochecknoglobals
+	codecsMu sync.RWMutex                      //nolint:gochecknoglobals
+)
+
+// RegisterCodec adds a codec to the registry, names must be unique.
+func RegisterCodec(codec Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if slices.ContainsFunc(codecs, func(c Codec) bool { return c.Name() == codec.Name() }) {
+		return zerr.Wrap(ErrCodecAlreadyRegistered, zap.String("codec", codec.Name()))
+	}
+
+	codecs = append(codecs, codec)
+
+	return nil
+}
+
+// Codecs returns the registered codecs in registration order.
+func Codecs() []Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	return slices.Clone(codecs)
+}
+
+func CodecByName(name string) (Codec, error) { //nolint:ireturn
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+	}
+
+	return nil, liberrors.NewInvalidStringEntityError("codec", name)
+}
+
+// DetectCodec returns the codec whose magic prefixes header.
+func DetectCodec(header []byte) (Codec, error) { //nolint:ireturn
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	for _, codec := range codecs {
+		if bytes.HasPrefix(header, codec.Magic()) {
+			return codec, nil
+		}
+	}
+
+	return nil, liberrors.NewInvalidStringEntityError("codec_magic", fmt.Sprintf("%x", header))
+}
+
+// MaxMagicSize returns the longest magic of the registered codecs.
+func MaxMagicSize() int {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	var size int
+
+	for _, codec := range codecs {
+		size = max(size, len(codec.Magic()))
+	}
+
+	return size
+}
+
+// Unpack decompresses reader into dst failing once the output exceeds maxDecompressBytes,
+// a non-positive maxDecompressBytes fails without reading. Use UnpackWithConfig to disable the limit.
+func Unpack(dst io.Writer, reader io.Reader, codec Codec, maxDecompressBytes int64) error {
+	if maxDecompressBytes <= 0 {
+		return newUnpackMaxDecompressLimitReachedError(0, maxDecompressBytes)
+	}
+
+	return UnpackWithConfig(dst, reader, codec, &UnpackConfig{
+		MaxDecompressBytes: maxDecompressBytes,
+		MaxRatio:           0,
+	})
+}
+
+// UnpackWithConfig decompresses reader into dst enforcing the size and ratio limits of cfg.
+func UnpackWithConfig(dst io.Writer, reader io.Reader, codec Codec, cfg *UnpackConfig) error {
+	return UnpackWithOptions(dst, reader, codec, cfg, nil)
+}
+
+// UnpackWithOptions is UnpackWithConfig reporting progress through opts, BytesIn counts compressed bytes.
+func UnpackWithOptions(dst io.Writer, reader io.Reader, codec Codec, cfg *UnpackConfig, opts *Options) error {
+	return UnpackContext(context.Background(), dst, reader, codec, cfg, opts)
+}
+
+// UnpackContext is UnpackWithOptions stopping once ctx is done.
+func UnpackContext(
+	ctx context.Context,
+	dst io.Writer,
+	reader io.Reader,
+	codec Codec,
+	cfg *UnpackConfig,
+	opts *Options,
+) error {
+	dst, reader, done := wrapIO(ctx, dst, reader, opts)
+	defer done()
+
+	compressed := NewCountingReader(reader)
+
+	codecReader, err := codec.NewReader(compressed)
+	if err != nil {
+		return zerr.Wrap(
+			fmt.Errorf("new reader: %w", err),
+			zap.String("codec", codec.Name()),
+		)
+	}
+
+	defer func() {
+		if err := codecReader.Close(); err != nil {
+			zerr.Wrap(err).WithField(
+				zap.String("codec", codec.Name()),
+			).LogError(libzap.Logger(), "codec reader close failed")
+		}
+	}()
+
+	if _, err := io.Copy(dst, NewLimitReader(codecReader, compressed, cfg)); err != nil {
+		return fmt.Errorf("copy: %w", err)
+	}
+
+	return nil
+}
+
+func Pack(dst io.Writer, reader io.Reader, codec Codec, level int) error {
+	return PackWithOptions(dst, reader, codec, level, nil)
+}
+
+// PackWithOptions is Pack reporting progress through opts, BytesOut counts compressed bytes.
+func PackWithOptions(dst io.Writer, reader io.Reader, codec Codec, level int, opts *Options) error {
+	return PackContext(context.Background(), dst, reader, codec, level, opts)
+}
+
+// PackContext is PackWithOptions stopping once ctx is done.
+func PackContext(ctx context.Context, dst io.Writer, reader io.Reader, codec Codec, level int, opts *Options) error {
+	dst, reader, done := wrapIO(ctx, dst, reader, opts)
+	defer done()
+
+	codecWriter, err := codec.NewWriter(dst, level)
+	if err != nil {
+		return zerr.Wrap(
+			fmt.Errorf("new writer: %w", err),
+			zap.String("codec", codec.Name()),
+			zap.Int("level", level),
+		)
+	}
+
+	if _, err := io.Copy(codecWriter, reader); err != nil {
+		if err := codecWriter.Close(); err != nil {
+			zerr.Wrap(err).WithField(
+				zap.String("codec", codec.Name()),
+			).LogError(libzap.Logger(), "codec writer close failed")
+		}
+
+		return fmt.Errorf("copy: %w", err)
+	}
+
+	if err := codecWriter.Close(); err != nil {
+		return fmt.Errorf("close writer: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package libio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+var gzMagic = []byte{0x1F, 0x8B} //nolint:gochecknoglobals
+
+var ErrGZTrailingData = errors.New("gz trailing data")
+
+// GZResult reports what UnpackGZWithConfig consumed from its input.
+// Trailing reads the input following the consumed members, it is nil when the input is exhausted.
+type GZResult struct {
+	Members  int
+	Consumed int64
+	Written  int64
+	Trailing io.Reader
+}
+
+// gzCodec writes with compress/gzip, or with the parallel writer when packCfg enables it.
+type gzCodec struct {
+	packCfg *GZPackConfig
+}
+
+func (*gzCodec) Name() string {
+	return "gz"
+}
+
+func (*gzCodec) Magic() []byte {
+	return gzMagic
+}
+
+func (*gzCodec) NewReader(reader io.Reader) (io.ReadCloser, error) {
+	gzReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("gz reader: %w", err)
+	}
+
+	return gzReader, nil
+}
+
+func (c *gzCodec) NewWriter(writer io.Writer, level int) (io.WriteCloser, error) {
+	if c.packCfg != nil && c.packCfg.Parallel {
+		parallelWriter, err := newParallelGZWriter(writer, level, c.packCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return parallelWriter, nil
+	}
+
+	gzWriter, err := gzip.NewWriterLevel(writer, level)
+	if err != nil {
+		return nil, fmt.Errorf("gz writer: %w", err)
+	}
+
+	return gzWriter, nil
+}
+
+// UnpackGZWithConfig decompresses gzip members of reader into dst, reading exactly the compressed bytes
+// of the members it decompresses. Data following them is returned in GZResult.Trailing, it is an
+// ErrGZTrailingData error unless it is another gzip member or cfg.AllowTrailingData is set.
+func UnpackGZWithConfig(dst io.Writer, reader io.Reader, cfg *GZConfig, limits *UnpackConfig) (*GZResult, error) {
+	buffered := bufio.NewReader(reader)
+	compressed := &countingByteReader{
+		CountingReader: NewCountingReader(buffered),
+		buffered:       buffered,
+	}
+
+	gzReader, err := gzip.NewReader(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("gz reader: %w", err)
+	}
+
+	defer func() {
+		if err := gzReader.Close(); err != nil {
+			zerr.Wrap(err).LogError(libzap.Logger(), "gz reader close failed")
+		}
+	}()
+
+	limited := NewLimitReader(gzReader, compressed.CountingReader, limits)
+	result := &GZResult{
+		Members:  0,
+		Consumed: 0,
+		Written:  0,
+		Trailing: nil,
+	}
+
+	for {
+		gzReader.Multistream(false)
+
+		_, err := io.Copy(dst, limited)
+
+		result.Written = limited.Written()
+
+		if err != nil {
+			return result, zerr.Wrap(
+				fmt.Errorf("copy member: %w", err),
+				zap.Int("member", result.Members),
+			)
+		}
+
+		result.Members++
+		result.Consumed = compressed.Count()
+
+		next, err := buffered.Peek(len(gzMagic))
+		if len(next) == 0 && errors.Is(err, io.EOF) {
+			return result, nil
+		}
+
+		isMember := bytes.Equal(next, gzMagic)
+
+		if !isMember && !cfg.AllowTrailingData {
+			return result, zerr.Wrap(ErrGZTrailingData, zap.Int64("consumed", result.Consumed))
+		}
+
+		if !isMember || !cfg.Multistream {
+			result.Trailing = buffered
+
+			return result, nil
+		}
+
+		if err := gzReader.Reset(compressed); err != nil {
+			return result, zerr.Wrap(
+				fmt.Errorf("gz reader reset: %w", err),
+				zap.Int("member", result.Members),
+			)
+		}
+	}
+}
+
+// countingByteReader lets gzip.Reader read the compressed input byte by byte without reading ahead.
+type countingByteReader struct {
+	*CountingReader
+
+	buffered *bufio.Reader
+}
+
+func (r *countingByteReader) ReadByte() (byte, error) {
+	b, err := r.buffered.ReadByte()
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	r.count++
+
+	return b, nil
+}
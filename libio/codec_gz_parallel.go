@@ -0,0 +1,251 @@
+package libio
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+const (
+	gzDictSize         = 32 << 10
+	gzMinBlockSize     = gzDictSize
+	gzDefaultBlockSize = 1 << 20
+	gzHeaderSize       = 10
+	gzMethodDeflate    = 8
+	gzXFLBest          = 2
+	gzXFLFastest       = 4
+	gzOSUnknown        = 255
+)
+
+var ErrWriterClosed = errors.New("writer closed")
+
+// NewGZPackConfig returns the default gzip options, parallel compression is opt-in as its output
+// differs byte for byte from compress/gzip.
+func NewGZPackConfig() *GZPackConfig {
+	return &GZPackConfig{
+		Level:     DefaultLevel,
+		Parallel:  false,
+		Workers:   0,
+		BlockSize: gzDefaultBlockSize,
+	}
+}
+
+// NewGZCodec returns the gz codec writing with cfg, its level argument overrides cfg.Level.
+func NewGZCodec(cfg *GZPackConfig) Codec { //nolint:ireturn
+	return &gzCodec{packCfg: cfg}
+}
+
+// PackGZWithConfig compresses reader into dst as a single gzip member, in parallel when cfg.Parallel is set.
+func PackGZWithConfig(dst io.Writer, reader io.Reader, cfg *GZPackConfig) error {
+	return Pack(dst, reader, &gzCodec{packCfg: cfg}, cfg.Level)
+}
+
+type gzBlock struct {
+	data []byte
+	err  error
+}
+
+// parallelGZWriter compresses blocks concurrently like pigz: every block is a raw deflate stream primed
+// with the last 32 KiB of the previous block and ended with a sync flush, the last one with a final block.
+// Blocks are written in order, the concatenation is one valid deflate stream. Only the block compressors
+// run in the background and each ends on its own, a writer dropped without Close leaks no goroutines.
+type parallelGZWriter struct {
+	writer    io.Writer
+	level     int
+	workers   int
+	blockSize int
+	block     []byte
+	dict      []byte
+	digest    uint32
+	size      uint32
+	pending   []chan gzBlock
+	err       error
+	closed    bool
+}
+
+func newParallelGZWriter(writer io.Writer, level int, cfg *GZPackConfig) (*parallelGZWriter, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, liberrors.NewInvalidIntEntityError("gz_level", level)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	blockSize := max(cfg.BlockSize, gzMinBlockSize)
+
+	w := &parallelGZWriter{
+		writer:    writer,
+		level:     level,
+		workers:   workers,
+		blockSize: blockSize,
+		block:     make([]byte, 0, blockSize),
+		dict:      nil,
+		digest:    0,
+		size:      0,
+		pending:   make([]chan gzBlock, 0, workers),
+		err:       nil,
+		closed:    false,
+	}
+
+	if _, err := writer.Write(gzHeader(level)); err != nil {
+		return nil, fmt.Errorf("write gz header: %w", err)
+	}
+
+	return w, nil
+}
+
+func (w *parallelGZWriter) Write(buff []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := len(buff)
+
+	w.digest = crc32.Update(w.digest, crc32.IEEETable, buff)
+	w.size += uint32(written) //nolint:gosec
+
+	for len(buff) > 0 {
+		n := min(len(buff), w.blockSize-len(w.block))
+		w.block = append(w.block, buff[:n]...)
+		buff = buff[n:]
+
+		if len(w.block) == w.blockSize {
+			if err := w.dispatch(false); err != nil {
+				return written - len(buff), err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close compresses the buffered block, waits for all blocks and writes the gzip trailer.
+// The underlying writer is not closed.
+func (w *parallelGZWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := w.dispatch(true); err != nil {
+		return err
+	}
+
+	for len(w.pending) > 0 {
+		if err := w.writeBlock(); err != nil {
+			return err
+		}
+	}
+
+	trailer := binary.LittleEndian.AppendUint32(nil, w.digest)
+	trailer = binary.LittleEndian.AppendUint32(trailer, w.size)
+
+	if _, err := w.writer.Write(trailer); err != nil {
+		return fmt.Errorf("write gz trailer: %w", err)
+	}
+
+	return nil
+}
+
+func (w *parallelGZWriter) dispatch(last bool) error {
+	// the number of blocks in flight is bounded by the workers, the oldest one is written first
+	if len(w.pending) == w.workers {
+		if err := w.writeBlock(); err != nil {
+			return err
+		}
+	}
+
+	block, dict := w.block, w.dict
+	result := make(chan gzBlock, 1)
+
+	go compressGZBlock(block, dict, w.level, last, result)
+
+	w.pending = append(w.pending, result)
+	w.dict = block[max(0, len(block)-gzDictSize):]
+	w.block = make([]byte, 0, w.blockSize)
+
+	return nil
+}
+
+// writeBlock waits for the oldest pending block and writes it, the first error is kept.
+func (w *parallelGZWriter) writeBlock() error {
+	block := <-w.pending[0]
+	w.pending = w.pending[1:]
+
+	if block.err != nil {
+		w.err = block.err
+		return w.err
+	}
+
+	if _, err := w.writer.Write(block.data); err != nil {
+		w.err = fmt.Errorf("write gz block: %w", err)
+		return w.err
+	}
+
+	return nil
+}
+
+func compressGZBlock(block, dict []byte, level int, last bool, result chan<- gzBlock) {
+	var buff bytes.Buffer
+
+	flateWriter, err := flate.NewWriterDict(&buff, level, dict)
+	if err != nil {
+		result <- gzBlock{data: nil, err: fmt.Errorf("flate writer: %w", err)}
+		return
+	}
+
+	if _, err := flateWriter.Write(block); err != nil {
+		result <- gzBlock{data: nil, err: fmt.Errorf("flate write: %w", err)}
+		return
+	}
+
+	if last {
+		err = flateWriter.Close()
+	} else {
+		err = flateWriter.Flush()
+	}
+
+	if err != nil {
+		result <- gzBlock{data: nil, err: fmt.Errorf("flate flush: %w", err)}
+		return
+	}
+
+	result <- gzBlock{data: buff.Bytes(), err: nil}
+}
+
+// gzHeader returns the header compress/gzip writes without name, comment and mtime.
+func gzHeader(level int) []byte {
+	header := make([]byte, gzHeaderSize)
+	copy(header, gzMagic)
+	header[2] = gzMethodDeflate
+
+	switch level {
+	case gzip.BestCompression:
+		header[8] = gzXFLBest
+	case gzip.BestSpeed:
+		header[8] = gzXFLFastest
+	}
+
+	header[9] = gzOSUnknown
+
+	return header
+}
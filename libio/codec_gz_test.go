@@ -0,0 +1,194 @@
+package libio_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestUnpackGZWithConfig(t *testing.T) {
+	t.Parallel()
+
+	first, second := packGZ(t, []byte("first")), packGZ(t, []byte("second"))
+	garbage := []byte("\x00\x00garbage")
+	input := bytes.Join([][]byte{first, second, garbage}, nil)
+	limits := &libio.UnpackConfig{MaxDecompressBytes: 0, MaxRatio: 0}
+
+	for name, tc := range map[string]struct {
+		cfg      libio.GZConfig
+		written  string
+		members  int
+		trailing []byte
+		err      error
+	}{
+		"multistream": {libio.GZConfig{Multistream: true, AllowTrailingData: true}, "firstsecond", 2, garbage, nil},
+		"single":      {libio.GZConfig{Multistream: false, AllowTrailingData: false}, "first", 1, input[len(first):], nil},
+		"strict": {
+			libio.GZConfig{Multistream: true, AllowTrailingData: false}, "firstsecond", 2, nil, libio.ErrGZTrailingData,
+		},
+	} {
+		var unpacked bytes.Buffer
+
+		result, err := libio.UnpackGZWithConfig(&unpacked, bytes.NewReader(input), &tc.cfg, limits)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s error: %v", name, err)
+		}
+
+		if unpacked.String() != tc.written || result.Members != tc.members {
+			t.Fatalf("%s unpacked %q members %d", name, unpacked.String(), result.Members)
+		}
+
+		if result.Consumed != int64(len(input)-len(garbage)) && tc.members == 2 {
+			t.Fatalf("%s consumed: %d", name, result.Consumed)
+		}
+
+		if tc.trailing == nil {
+			continue
+		}
+
+		trailing, err := io.ReadAll(result.Trailing)
+		checkError(t, err)
+
+		if !bytes.Equal(trailing, tc.trailing) || result.Consumed != int64(len(input)-len(tc.trailing)) {
+			t.Fatalf("%s trailing %q consumed %d", name, trailing, result.Consumed)
+		}
+	}
+}
+
+func packGZ(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var packed bytes.Buffer
+
+	checkError(t, libio.PackGZ(&packed, bytes.NewReader(data)))
+
+	return packed.Bytes()
+}
+
+func TestPackGZParallel(t *testing.T) {
+	t.Parallel()
+
+	cfg := libio.NewGZPackConfig()
+	cfg.Parallel = true
+	cfg.Workers = 3
+	cfg.BlockSize = 64 << 10
+
+	for _, size := range []int{0, 100, cfg.BlockSize, 5*cfg.BlockSize + 17} {
+		data := testPackData(size)
+
+		var packed bytes.Buffer
+
+		checkError(t, libio.PackGZWithConfig(&packed, bytes.NewReader(data), cfg))
+
+		gzReader, err := gzip.NewReader(&packed)
+		checkError(t, err)
+
+		unpacked, err := io.ReadAll(gzReader)
+		checkError(t, err)
+
+		if !bytes.Equal(unpacked, data) {
+			t.Fatalf("size %d: unpacked data differs", size)
+		}
+	}
+}
+
+func TestPackGZDefaultSerial(t *testing.T) {
+	t.Parallel()
+
+	data := testPackData(3 << 20)
+
+	var packed, expected bytes.Buffer
+
+	checkError(t, libio.PackGZWithConfig(&packed, bytes.NewReader(data), libio.NewGZPackConfig()))
+
+	gzWriter := gzip.NewWriter(&expected)
+	_, err := gzWriter.Write(data)
+	checkError(t, err)
+	checkError(t, gzWriter.Close())
+
+	if !bytes.Equal(packed.Bytes(), expected.Bytes()) {
+		t.Fatal("default gz pack differs from compress/gzip")
+	}
+}
+
+var errBrokenWriter = errors.New("broken writer")
+
+// brokenWriter accepts the first limit bytes and fails afterwards.
+type brokenWriter struct {
+	limit int
+}
+
+func (w *brokenWriter) Write(buff []byte) (int, error) {
+	if len(buff) > w.limit {
+		return 0, errBrokenWriter
+	}
+
+	w.limit -= len(buff)
+
+	return len(buff), nil
+}
+
+//nolint:paralleltest // counts the goroutines of the process
+func TestPackGZParallelWithoutClose(t *testing.T) {
+	cfg := libio.NewGZPackConfig()
+	cfg.Parallel = true
+	cfg.Workers = 2
+	cfg.BlockSize = 64 << 10
+
+	before := runtime.NumGoroutine()
+
+	writer, err := libio.NewGZCodec(cfg).NewWriter(&brokenWriter{limit: 1 << 10}, cfg.Level)
+	checkError(t, err)
+
+	if _, err := writer.Write(testPackData(16 * cfg.BlockSize)); !errors.Is(err, errBrokenWriter) {
+		t.Fatalf("write error: %v", err)
+	}
+
+	// the writer is dropped without Close like on an error path
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d > %d", runtime.NumGoroutine(), before)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func BenchmarkPackGZ(b *testing.B) {
+	data := testPackData(32 << 20)
+	parallel := libio.NewGZPackConfig()
+	parallel.Parallel = true
+	serial := libio.NewGZPackConfig()
+
+	for name, cfg := range map[string]*libio.GZPackConfig{"parallel": parallel, "compress/gzip": serial} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+
+			for range b.N {
+				if err := libio.PackGZWithConfig(io.Discard, bytes.NewReader(data), cfg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// testPackData returns compressible data with some entropy.
+func testPackData(size int) []byte {
+	data := make([]byte, size)
+	rnd := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+
+	for ind := range data {
+		data[ind] = "initramfs"[rnd.IntN(9)]
+	}
+
+	return data
+}
@@ -0,0 +1,102 @@
+package libio_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("initramfs "), 1024)
+
+	for _, name := range []string{"gz", "xz"} {
+		codec, err := libio.CodecByName(name)
+		checkError(t, err)
+
+		var packed, unpacked bytes.Buffer
+
+		checkError(t, libio.Pack(&packed, bytes.NewReader(data), codec, libio.DefaultLevel))
+
+		detected, err := libio.DetectCodec(packed.Bytes()[:libio.MaxMagicSize()])
+		checkError(t, err)
+
+		if detected.Name() != codec.Name() {
+			t.Fatalf("detected codec: %s != %s", detected.Name(), codec.Name())
+		}
+
+		checkError(t, libio.Unpack(&unpacked, bytes.NewReader(packed.Bytes()), detected, int64(len(data))))
+
+		if !bytes.Equal(unpacked.Bytes(), data) {
+			t.Fatalf("%s unpacked data differs", name)
+		}
+
+		unpacked.Reset()
+
+		if err := libio.Unpack(&unpacked, bytes.NewReader(packed.Bytes()), detected, int64(len(data)-1)); err == nil {
+			t.Fatalf("%s decompress limit not enforced", name)
+		}
+
+		if unpacked.Len() != len(data)-1 {
+			t.Fatalf("%s unpacked past the limit: %d", name, unpacked.Len())
+		}
+
+		err = libio.Unpack(io.Discard, bytes.NewReader(packed.Bytes()), detected, 0)
+		if !libio.IsUnpackMaxDecompressLimitReachedError(err) {
+			t.Fatalf("%s zero limit: %v", name, err)
+		}
+	}
+}
+
+func TestPackXZ(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("firmware "), 4096)
+
+	crc64 := libio.NewXZKernelConfig()
+	crc64.Check = libio.XZCheckCRC64
+
+	invalid := libio.NewXZKernelConfig()
+	invalid.DictPreset = 10
+
+	for name, cfg := range map[string]*libio.XZConfig{
+		"kernel": libio.NewXZKernelConfig(),
+		"crc64":  crc64,
+	} {
+		var packed, unpacked bytes.Buffer
+
+		checkError(t, libio.PackXZ(&packed, bytes.NewReader(data), cfg))
+		checkError(t, libio.UnpackXZ(&unpacked, bytes.NewReader(packed.Bytes())))
+
+		if !bytes.Equal(unpacked.Bytes(), data) {
+			t.Fatalf("%s unpacked data differs", name)
+		}
+	}
+
+	if err := libio.PackXZ(io.Discard, bytes.NewReader(data), invalid); err == nil {
+		t.Fatal("invalid preset accepted")
+	}
+}
+
+func TestRegisterCodecDuplicate(t *testing.T) {
+	t.Parallel()
+
+	codec, err := libio.CodecByName("xz")
+	checkError(t, err)
+
+	if err := libio.RegisterCodec(codec); !errors.Is(err, libio.ErrCodecAlreadyRegistered) {
+		t.Fatalf("duplicate codec registered: %v", err)
+	}
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
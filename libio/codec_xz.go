@@ -0,0 +1,143 @@
+package libio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/liberrors"
+	ulxz "github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+	"github.com/xi2/xz"
+)
+
+const (
+	kib                 = 1 << 10
+	mib                 = 1 << 20
+	xzDefaultDictPreset = 6
+	xzKernelDictSize    = 1 * mib
+	xzDefaultLC         = 3
+	xzDefaultPB         = 2
+	xzMaxDictPreset     = 9
+	xzMinDictPreset     = 0
+	xzPresetZeroDict    = 256 * kib
+	xzPresetsDictCount  = 10
+)
+
+var (
+	xzMagic = []byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00} //nolint:gochecknoglobals
+
+	// xzPresetDictSizes follows the dictionary sizes of the xz utils presets.
+	xzPresetDictSizes = [xzPresetsDictCount]int{ //nolint:gochecknoglobals
+		xzPresetZeroDict, 1 * mib, 2 * mib, 4 * mib, 4 * mib, 8 * mib, 8 * mib, 16 * mib, 32 * mib, 64 * mib,
+	}
+)
+
+// NewXZKernelConfig returns the options the kernel documents for initramfs images,
+// the same as xz --check=crc32 --lzma2=dict=1MiB.
+func NewXZKernelConfig() *XZConfig {
+	return &XZConfig{
+		Check:              XZCheckCRC32,
+		DictPreset:         xzDefaultDictPreset,
+		DictSize:           xzKernelDictSize,
+		LiteralContextBits: xzDefaultLC,
+		LiteralPosBits:     0,
+		PosBits:            xzDefaultPB,
+	}
+}
+
+// PackXZ compresses reader into dst as a single block xz stream with the LZMA2 filter only.
+func PackXZ(dst io.Writer, reader io.Reader, cfg *XZConfig) error {
+	return Pack(dst, reader, &xzCodec{cfg: cfg}, DefaultLevel)
+}
+
+// xzCodec decodes with xi2/xz and encodes with ulikunitz/xz, cfg nil means NewXZKernelConfig.
+// The encoder writes a single LZMA2 filter, BCJ filters are not supported.
+type xzCodec struct {
+	cfg *XZConfig
+}
+
+func (*xzCodec) Name() string {
+	return "xz"
+}
+
+func (*xzCodec) Magic() []byte {
+	return xzMagic
+}
+
+func (*xzCodec) NewReader(reader io.Reader) (io.ReadCloser, error) {
+	xzReader, err := xz.NewReader(reader, 0)
+	if err != nil {
+		return nil, fmt.Errorf("xz reader: %w", err)
+	}
+
+	return io.NopCloser(xzReader), nil
+}
+
+// NewWriter uses level as the dictionary preset unless it is DefaultLevel, only the dictionary size
+// follows the level.
+func (c *xzCodec) NewWriter(writer io.Writer, level int) (io.WriteCloser, error) {
+	cfg := NewXZKernelConfig()
+	if c.cfg != nil {
+		cfg = c.cfg
+	}
+
+	if level != DefaultLevel {
+		withLevel := *cfg
+		withLevel.DictPreset, withLevel.DictSize = level, 0
+		cfg = &withLevel
+	}
+
+	writerCfg, err := xzWriterConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	xzWriter, err := writerCfg.NewWriter(writer)
+	if err != nil {
+		return nil, fmt.Errorf("xz writer: %w", err)
+	}
+
+	return xzWriter, nil
+}
+
+func xzWriterConfig(cfg *XZConfig) (*ulxz.WriterConfig, error) {
+	if cfg.DictPreset < xzMinDictPreset || cfg.DictPreset > xzMaxDictPreset {
+		return nil, liberrors.NewInvalidIntEntityError("xz_dict_preset", cfg.DictPreset)
+	}
+
+	dictSize := cfg.DictSize
+	if dictSize == 0 {
+		dictSize = xzPresetDictSizes[cfg.DictPreset]
+	}
+
+	var checkSum byte
+
+	switch cfg.Check {
+	case XZCheckCRC64:
+		checkSum = ulxz.CRC64
+	case XZCheckUnknown:
+		fallthrough
+	case XZCheckCRC32:
+		checkSum = ulxz.CRC32
+	}
+
+	writerCfg := &ulxz.WriterConfig{
+		Properties: &lzma.Properties{
+			LC: cfg.LiteralContextBits,
+			LP: cfg.LiteralPosBits,
+			PB: cfg.PosBits,
+		},
+		DictCap:    dictSize,
+		BufSize:    0,
+		BlockSize:  0,
+		CheckSum:   checkSum,
+		NoCheckSum: false,
+		Matcher:    lzma.HashTable4,
+	}
+
+	if err := writerCfg.Verify(); err != nil {
+		return nil, fmt.Errorf("verify xz config: %w", err)
+	}
+
+	return writerCfg, nil
+}
@@ -0,0 +1,27 @@
+package libio
+
+type XZConfig struct {
+	Check              XZCheckEnum `yaml:"check"              env:"CHECK"                env-default:"crc32" env-description:"Set the integrity check (crc32, crc64), the kernel decoder requires crc32."`
+	DictPreset         int         `yaml:"dictPreset"         env:"DICT_PRESET"          env-default:"6"     env-description:"Select the LZMA2 dictionary size of an xz preset (0-9), the other preset settings and BCJ filters are not applied."`
+	DictSize           int         `yaml:"dictSize"           env:"DICT_SIZE"            env-default:"0"     env-description:"Override the LZMA2 dictionary size in bytes, 0 uses the preset one."`
+	LiteralContextBits int         `yaml:"literalContextBits" env:"LITERAL_CONTEXT_BITS" env-default:"3"     env-description:"Set the LZMA2 lc option, lc + lp must not exceed 4."`
+	LiteralPosBits     int         `yaml:"literalPosBits"     env:"LITERAL_POS_BITS"     env-default:"0"     env-description:"Set the LZMA2 lp option."`
+	PosBits            int         `yaml:"posBits"            env:"POS_BITS"             env-default:"2"     env-description:"Set the LZMA2 pb option."`
+}
+
+type UnpackConfig struct {
+	MaxDecompressBytes int64 `yaml:"maxDecompressBytes" env:"MAX_DECOMPRESS_BYTES" env-default:"524288000" env-description:"Cap the decompressed size in bytes, 0 disables the limit."`
+	MaxRatio           int64 `yaml:"maxRatio"           env:"MAX_RATIO"            env-default:"0"         env-description:"Cap the decompressed to compressed size ratio, 0 disables the limit."`
+}
+
+type GZConfig struct {
+	Multistream       bool `yaml:"multistream"       env:"MULTISTREAM"         env-default:"true"  env-description:"Decompress concatenated gzip members as one stream, false stops after the first member."`
+	AllowTrailingData bool `yaml:"allowTrailingData" env:"ALLOW_TRAILING_DATA" env-default:"false" env-description:"Stop at non gzip data following the members instead of failing, it is returned to the caller."`
+}
+
+type GZPackConfig struct {
+	Level     int  `yaml:"level"     env:"LEVEL"      env-default:"-1"      env-description:"Set the gzip compression level (-2 huffman only, -1 default, 0-9)."`
+	Parallel  bool `yaml:"parallel"  env:"PARALLEL"   env-default:"false"   env-description:"Compress blocks in parallel like pigz, the output is a standard single member gzip stream but not byte identical to compress/gzip."`
+	Workers   int  `yaml:"workers"   env:"WORKERS"    env-default:"0"       env-description:"Number of parallel compression workers, 0 uses GOMAXPROCS."`
+	BlockSize int  `yaml:"blockSize" env:"BLOCK_SIZE" env-default:"1048576" env-description:"Size of the input blocks compressed in parallel in bytes."`
+}
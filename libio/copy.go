@@ -0,0 +1,139 @@
+package libio
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the bandwidth of libio operations, it can be shared
+// by concurrent operations to cap their total bandwidth.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter of bytesPerSecond allowing bursts of burst bytes,
+// burst 0 means one second worth of bytes.
+func NewRateLimiter(bytesPerSecond, burst int64) *RateLimiter {
+	if burst <= 0 {
+		burst = bytesPerSecond
+	}
+
+	return &RateLimiter{
+		mu:     sync.Mutex{},
+		rate:   float64(bytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// WaitN takes n tokens, waiting until the bucket is no longer in debt or ctx is done.
+// Transfers bigger than the burst are allowed and paid back by waiting.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 || l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
+
+// CopyContext is io.Copy stopping with the ctx error once ctx is done, throttled by opts.RateLimiter
+// and reporting progress through opts.
+func CopyContext(ctx context.Context, dst io.Writer, reader io.Reader, opts *Options) (int64, error) {
+	dst, reader, done := wrapIO(ctx, dst, reader, opts)
+	defer done()
+
+	return io.Copy(dst, reader) //nolint:wrapcheck
+}
+
+// wrapIO applies ctx and the hooks of opts to dst and reader, the rate limiter is charged for the bytes
+// read only, so a copy runs at the configured rate. done reports the final progress.
+func wrapIO(ctx context.Context, dst io.Writer, reader io.Reader, opts *Options) (io.Writer, io.Reader, func()) {
+	done := func() {}
+
+	if opts != nil && opts.Hashes != nil {
+		dst, reader = opts.Hashes.writer(dst), opts.Hashes.reader(reader)
+	}
+
+	if tracker := newProgressTracker(opts); tracker != nil {
+		dst, reader, done = tracker.writer(dst), tracker.reader(reader), tracker.done
+	}
+
+	var limiter *RateLimiter
+	if opts != nil {
+		limiter = opts.RateLimiter
+	}
+
+	if ctx.Done() == nil && limiter == nil {
+		return dst, reader, done
+	}
+
+	return &contextWriter{ctx: ctx, writer: dst},
+		&contextReader{ctx: ctx, reader: reader, limiter: limiter},
+		done
+}
+
+type contextReader struct {
+	ctx     context.Context //nolint:containedctx
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+func (r *contextReader) Read(buff []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	n, err := r.reader.Read(buff)
+
+	if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+type contextWriter struct {
+	ctx    context.Context //nolint:containedctx
+	writer io.Writer
+}
+
+func (w *contextWriter) Write(buff []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return w.writer.Write(buff) //nolint:wrapcheck
+}
@@ -0,0 +1,67 @@
+package libio_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestCopyContextRateLimit(t *testing.T) {
+	t.Parallel()
+
+	const (
+		rate  = 256 << 10
+		burst = 16 << 10
+	)
+
+	data := make([]byte, 128<<10)
+	opts := &libio.Options{
+		Progress:         nil,
+		ProgressInterval: 0,
+		Total:            0,
+		RateLimiter:      libio.NewRateLimiter(rate, burst),
+		Hashes:           nil,
+	}
+
+	start := time.Now()
+
+	written, err := libio.CopyContext(context.Background(), io.Discard, bytes.NewReader(data), opts)
+	checkError(t, err)
+
+	elapsed := time.Since(start)
+
+	// the burst is free, the rest is copied at the rate: 112 KiB at 256 KiB/s
+	expected := time.Duration(float64(len(data)-burst) / rate * float64(time.Second))
+	if written != int64(len(data)) || elapsed < expected*9/10 || elapsed > expected*3/2 {
+		t.Fatalf("copied %d in %s, expected %s", written, elapsed, expected)
+	}
+}
+
+func TestUnpackContextCancel(t *testing.T) {
+	t.Parallel()
+
+	codec, err := libio.CodecByName("gz")
+	checkError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	limits := &libio.UnpackConfig{MaxDecompressBytes: 0, MaxRatio: 0}
+	packed := packGZ(t, []byte("initramfs"))
+
+	err = libio.UnpackContext(ctx, io.Discard, bytes.NewReader(packed), codec, limits, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unpack error: %v", err)
+	}
+
+	err = libio.CloneReaderContext(ctx, bytes.NewReader(packed), filepath.Join(t.TempDir(), "clone"), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("clone reader error: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package libio
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+// Digests are the sums of the bytes that went through a HashReader or HashWriter.
+type Digests struct {
+	Bytes int64
+	Sums  map[HashEnum][]byte
+}
+
+// Hex returns the hex encoded sum of hashType, empty when it was not computed.
+func (d *Digests) Hex(hashType HashEnum) string {
+	return hex.EncodeToString(d.Sums[hashType])
+}
+
+type multiHash struct {
+	types  []HashEnum
+	hashes []hash.Hash
+	writer io.Writer
+	bytes  int64
+}
+
+func newMultiHash(hashTypes []HashEnum) (*multiHash, error) {
+	multi := &multiHash{
+		types:  hashTypes,
+		hashes: make([]hash.Hash, 0, len(hashTypes)),
+		writer: nil,
+		bytes:  0,
+	}
+
+	writers := make([]io.Writer, 0, len(hashTypes))
+
+	for _, hashType := range hashTypes {
+		var hasher hash.Hash
+
+		switch hashType {
+		case HashSHA256:
+			hasher = sha256.New()
+		case HashSHA1:
+			hasher = sha1.New() //nolint:gosec
+		case HashCRC32:
+			hasher = crc32.NewIEEE()
+		case HashXXHash:
+			hasher = xxhash.New()
+		case HashUnknown:
+			fallthrough
+		default:
+			return nil, liberrors.NewInvalidStringEntityError("hash", hashType.String())
+		}
+
+		multi.hashes = append(multi.hashes, hasher)
+		writers = append(writers, hasher)
+	}
+
+	multi.writer = io.MultiWriter(writers...)
+
+	return multi, nil
+}
+
+func (m *multiHash) update(buff []byte) {
+	_, _ = m.writer.Write(buff) // hash writes never fail
+	m.bytes += int64(len(buff))
+}
+
+func (m *multiHash) digests() *Digests {
+	digests := &Digests{
+		Bytes: m.bytes,
+		Sums:  make(map[HashEnum][]byte, len(m.hashes)),
+	}
+
+	for ind, hasher := range m.hashes {
+		digests.Sums[m.types[ind]] = hasher.Sum(nil)
+	}
+
+	return digests
+}
+
+// StreamHashes computes digests while streaming through the libio operations it is passed to
+// in Options, e.g. the compressed and decompressed bytes of UnpackWithOptions.
+// The sums cover every operation it was passed to.
+type StreamHashes struct {
+	in  *multiHash
+	out *multiHash
+}
+
+func NewStreamHashes(hashTypes ...HashEnum) (*StreamHashes, error) {
+	in, err := newMultiHash(hashTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := newMultiHash(hashTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamHashes{in: in, out: out}, nil
+}
+
+// In returns the digests of the bytes read from the source.
+func (h *StreamHashes) In() *Digests {
+	return h.in.digests()
+}
+
+// Out returns the digests of the bytes written to the destination.
+func (h *StreamHashes) Out() *Digests {
+	return h.out.digests()
+}
+
+func (h *StreamHashes) reader(reader io.Reader) io.Reader {
+	return &HashReader{reader: reader, hashes: h.in}
+}
+
+func (h *StreamHashes) writer(writer io.Writer) io.Writer {
+	return &HashWriter{writer: writer, hashes: h.out}
+}
+
+// HashReader computes digests of the bytes read through it.
+type HashReader struct {
+	reader io.Reader
+	hashes *multiHash
+}
+
+func NewHashReader(reader io.Reader, hashTypes ...HashEnum) (*HashReader, error) {
+	hashes, err := newMultiHash(hashTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashReader{
+		reader: reader,
+		hashes: hashes,
+	}, nil
+}
+
+func (r *HashReader) Read(buff []byte) (int, error) {
+	n, err := r.reader.Read(buff)
+	r.hashes.update(buff[:n])
+
+	return n, err //nolint:wrapcheck
+}
+
+// Digests returns the digests of the bytes read so far.
+func (r *HashReader) Digests() *Digests {
+	return r.hashes.digests()
+}
+
+// HashWriter computes digests of the bytes written through it.
+type HashWriter struct {
+	writer io.Writer
+	hashes *multiHash
+}
+
+func NewHashWriter(writer io.Writer, hashTypes ...HashEnum) (*HashWriter, error) {
+	hashes, err := newMultiHash(hashTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashWriter{
+		writer: writer,
+		hashes: hashes,
+	}, nil
+}
+
+func (w *HashWriter) Write(buff []byte) (int, error) {
+	n, err := w.writer.Write(buff)
+	w.hashes.update(buff[:n])
+
+	return n, err //nolint:wrapcheck
+}
+
+// Digests returns the digests of the bytes written so far.
+func (w *HashWriter) Digests() *Digests {
+	return w.hashes.digests()
+}
@@ -0,0 +1,56 @@
+package libio
+
+import (
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=HashEnum -linecomment -output hash_enum_string.go
+type HashEnum int //nolint:recvcheck
+
+const (
+	HashUnknown HashEnum = iota // unknown
+	HashSHA256  HashEnum = iota // sha256
+	HashSHA1    HashEnum = iota // sha1
+	HashCRC32   HashEnum = iota // crc32
+	HashXXHash  HashEnum = iota // xxhash
+)
+
+func (e *HashEnum) SetValue(value string) error {
+	hash := HashFromString(value)
+	if hash == HashUnknown {
+		return liberrors.NewInvalidStringEntityError("hash", value)
+	}
+
+	*e = hash
+
+	return nil
+}
+
+func (e HashEnum) MarshalText() ([]byte, error) {
+	if e == HashUnknown {
+		return nil, liberrors.NewInvalidStringEntityError("hash", e.String())
+	}
+
+	return []byte(e.String()), nil
+}
+
+func (e *HashEnum) UnmarshalText(text []byte) error {
+	return e.SetValue(string(text))
+}
+
+func HashFromString(value string) HashEnum {
+	switch strings.ToLower(value) {
+	case "sha256":
+		return HashSHA256
+	case "sha1":
+		return HashSHA1
+	case "crc32":
+		return HashCRC32
+	case "xxhash":
+		return HashXXHash
+	default:
+		return HashUnknown
+	}
+}
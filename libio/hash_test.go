@@ -0,0 +1,105 @@
+package libio_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestHashReaderWriter(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("initramfs "), 4096)
+	packed := packGZ(t, data)
+
+	hashReader, err := libio.NewHashReader(bytes.NewReader(packed), libio.HashSHA256, libio.HashXXHash)
+	checkError(t, err)
+
+	hashWriter, err := libio.NewHashWriter(io.Discard, libio.HashSHA256, libio.HashSHA1, libio.HashCRC32)
+	checkError(t, err)
+
+	checkError(t, libio.UnpackGZ(hashWriter, hashReader, int64(len(data))))
+
+	in, out := hashReader.Digests(), hashWriter.Digests()
+	packedSum, dataSum := sha256.Sum256(packed), sha256.Sum256(data)
+
+	if in.Bytes != int64(len(packed)) || in.Hex(libio.HashSHA256) != hex.EncodeToString(packedSum[:]) {
+		t.Fatalf("reader digests: %d %s", in.Bytes, in.Hex(libio.HashSHA256))
+	}
+
+	if out.Bytes != int64(len(data)) || out.Hex(libio.HashSHA256) != hex.EncodeToString(dataSum[:]) {
+		t.Fatalf("writer digests: %d %s", out.Bytes, out.Hex(libio.HashSHA256))
+	}
+
+	crc := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+	if out.Hex(libio.HashCRC32) != hex.EncodeToString(crc) {
+		t.Fatalf("crc32 digest: %s", out.Hex(libio.HashCRC32))
+	}
+
+	if len(in.Sums[libio.HashXXHash]) != 8 || out.Hex(libio.HashXXHash) != "" {
+		t.Fatalf("xxhash digests: %s %s", in.Hex(libio.HashXXHash), out.Hex(libio.HashXXHash))
+	}
+
+	if _, err := libio.NewHashReader(bytes.NewReader(nil), libio.HashUnknown); err == nil {
+		t.Fatal("unknown hash accepted")
+	}
+}
+
+func TestStreamHashes(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("initramfs "), 4096)
+	dataSum := sha256.Sum256(data)
+
+	hashes, err := libio.NewStreamHashes(libio.HashSHA256)
+	checkError(t, err)
+
+	var packed bytes.Buffer
+
+	opts := &libio.Options{
+		Progress:         nil,
+		ProgressInterval: 0,
+		Total:            0,
+		RateLimiter:      nil,
+		Hashes:           hashes,
+	}
+	checkError(t, libio.PackWithOptions(&packed, bytes.NewReader(data), libio.NewGZCodec(nil), libio.DefaultLevel, opts))
+
+	packedSum := sha256.Sum256(packed.Bytes())
+
+	if in, out := hashes.In(), hashes.Out(); in.Bytes != int64(len(data)) ||
+		in.Hex(libio.HashSHA256) != hex.EncodeToString(dataSum[:]) ||
+		out.Bytes != int64(packed.Len()) || out.Hex(libio.HashSHA256) != hex.EncodeToString(packedSum[:]) {
+		t.Fatalf("pack digests: %+v %+v", in, out)
+	}
+
+	opts.Hashes, err = libio.NewStreamHashes(libio.HashSHA256)
+	checkError(t, err)
+
+	limits := &libio.UnpackConfig{MaxDecompressBytes: 0, MaxRatio: 0}
+	checkError(t, libio.UnpackWithOptions(io.Discard, &packed, libio.NewGZCodec(nil), limits, opts))
+
+	in, out := opts.Hashes.In(), opts.Hashes.Out()
+	if in.Hex(libio.HashSHA256) != hex.EncodeToString(packedSum[:]) ||
+		out.Bytes != int64(len(data)) || out.Hex(libio.HashSHA256) != hex.EncodeToString(dataSum[:]) {
+		t.Fatalf("unpack digests: %+v %+v", in, out)
+	}
+
+	opts.Hashes, err = libio.NewStreamHashes(libio.HashSHA256)
+	checkError(t, err)
+
+	dst := filepath.Join(t.TempDir(), "image")
+	checkError(t, libio.CloneReaderContext(t.Context(), bytes.NewReader(data), dst, opts))
+
+	out = opts.Hashes.Out()
+	if out.Bytes != int64(len(data)) || out.Hex(libio.HashSHA256) != hex.EncodeToString(dataSum[:]) {
+		t.Fatalf("clone digests: %+v", out)
+	}
+}
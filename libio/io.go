@@ -1,43 +1,89 @@
 package libio
 
 import (
-	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/grinderz/go-libs/libzap"
 	"github.com/grinderz/go-libs/libzap/zerr"
-	"github.com/xi2/xz"
 	"go.uber.org/zap"
 )
 
-type unpackMaxDecompressLimitReachedError struct {
-	writtenBytes       int64
-	maxDecompressBytes int64
+// UnpackMaxDecompressLimitReachedError is returned by unpackers when the decompressed output exceeds
+// MaxDecompressBytes or, when MaxRatio is set, MaxRatio times the compressed input.
+type UnpackMaxDecompressLimitReachedError struct {
+	WrittenBytes       int64
+	MaxDecompressBytes int64
+	CompressedBytes    int64
+	MaxRatio           int64
 }
 
-func (e *unpackMaxDecompressLimitReachedError) Error() string {
+func (e *UnpackMaxDecompressLimitReachedError) Error() string {
+	if e.MaxRatio > 0 {
+		return fmt.Sprintf(
+			"unpack max decompress ratio reached: written[%d] compressed[%d] ratio[%d]",
+			e.WrittenBytes,
+			e.CompressedBytes,
+			e.MaxRatio,
+		)
+	}
+
 	return fmt.Sprintf(
 		"unpack max decompress limit reached: written[%d] limit[%d]",
-		e.writtenBytes,
-		e.maxDecompressBytes,
+		e.WrittenBytes,
+		e.MaxDecompressBytes,
 	)
 }
 
+func IsUnpackMaxDecompressLimitReachedError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var e *UnpackMaxDecompressLimitReachedError
+
+	return errors.As(err, &e)
+}
+
 func newUnpackMaxDecompressLimitReachedError(writtenBytes, maxDecompressBytes int64) error {
 	return zerr.Wrap(
-		&unpackMaxDecompressLimitReachedError{
-			writtenBytes:       writtenBytes,
-			maxDecompressBytes: maxDecompressBytes,
+		&UnpackMaxDecompressLimitReachedError{
+			WrittenBytes:       writtenBytes,
+			MaxDecompressBytes: maxDecompressBytes,
+			CompressedBytes:    0,
+			MaxRatio:           0,
 		},
 		zap.Int64("written_bytes", writtenBytes),
 		zap.Int64("max_decompress_bytes", maxDecompressBytes),
 	)
 }
 
+func newUnpackMaxDecompressRatioReachedError(writtenBytes, compressedBytes, maxRatio int64) error {
+	return zerr.Wrap(
+		&UnpackMaxDecompressLimitReachedError{
+			WrittenBytes:       writtenBytes,
+			MaxDecompressBytes: 0,
+			CompressedBytes:    compressedBytes,
+			MaxRatio:           maxRatio,
+		},
+		zap.Int64("written_bytes", writtenBytes),
+		zap.Int64("compressed_bytes", compressedBytes),
+		zap.Int64("max_ratio", maxRatio),
+	)
+}
+
+// CloneReader atomically writes reader to dst, dst is left untouched when the copy fails.
+// An existing dst keeps its mode, a new one is created with 0644.
 func CloneReader(reader io.Reader, dst string) error {
-	dstFile, err := os.Create(dst)
+	return CloneReaderContext(context.Background(), reader, dst, nil)
+}
+
+// CloneReaderContext is CloneReader stopping once ctx is done, throttled and reporting progress through opts.
+func CloneReaderContext(ctx context.Context, reader io.Reader, dst string, opts *Options) error {
+	dstFile, err := CreateAtomicFile(dst, nil)
 	if err != nil {
 		return fmt.Errorf("create dst: %w", err)
 	}
@@ -50,70 +96,100 @@ func CloneReader(reader io.Reader, dst string) error {
 		}
 	}()
 
-	if _, err := io.Copy(dstFile, reader); err != nil {
+	if _, err := CopyContext(ctx, dstFile, reader, opts); err != nil {
 		return fmt.Errorf("copy: %w", err)
 	}
 
-	if err = dstFile.Sync(); err != nil {
-		return fmt.Errorf("sync dst: %w", err)
+	if err := dstFile.Commit(); err != nil {
+		return fmt.Errorf("commit dst: %w", err)
 	}
 
 	return nil
 }
 
-func UnpackXZ(dst io.Writer, reader io.Reader) error {
-	xzReader, err := xz.NewReader(reader, 0)
-	if err != nil {
-		return fmt.Errorf("new reader: %w", err)
-	}
+// CopyFile atomically copies src to dst keeping the src permissions.
+func CopyFile(src, dst string) error {
+	_, err := copyFile(src, dst, func(dst, src *os.File, _ int64) (CloneMethodEnum, error) {
+		if _, err := io.Copy(dst, src); err != nil {
+			return CloneMethodCopy, fmt.Errorf("copy: %w", err)
+		}
 
-	if _, err = io.Copy(dst, xzReader); err != nil {
-		return fmt.Errorf("copy: %w", err)
-	}
+		return CloneMethodCopy, nil
+	})
 
-	return nil
+	return err
 }
 
-func UnpackGZ(dst io.Writer, reader io.Reader, maxDecompressBytes int64) error {
-	gzReader, err := gzip.NewReader(reader)
+// CloneFile is CopyFile sharing the data blocks with src when the filesystem supports it.
+// On Linux it tries a FICLONE reflink, copy_file_range for files without holes and a SEEK_DATA/SEEK_HOLE
+// sparse copy before falling back to a plain copy, the returned method tells which one was used.
+func CloneFile(src, dst string) (CloneMethodEnum, error) {
+	return copyFile(src, dst, cloneFileData)
+}
+
+func copyFile(
+	src, dst string,
+	copyData func(dst, src *os.File, size int64) (CloneMethodEnum, error),
+) (CloneMethodEnum, error) {
+	srcFile, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("reader: %w", err)
+		return CloneMethodUnknown, fmt.Errorf("open src: %w", err)
 	}
 
 	defer func() {
-		if err := gzReader.Close(); err != nil {
+		if err := srcFile.Close(); err != nil {
 			zerr.Wrap(err).WithField(
-				zap.String("gz_reader", gzReader.Name),
-			).LogError(libzap.Logger(), "gz reader close failed")
+				zap.String("src", src),
+			).LogError(libzap.Logger(), "src file close failed")
 		}
 	}()
 
-	written, err := io.CopyN(dst, gzReader, maxDecompressBytes)
+	stat, err := srcFile.Stat()
 	if err != nil {
-		return fmt.Errorf("copy: %w", err)
+		return CloneMethodUnknown, fmt.Errorf("stat src: %w", err)
 	}
 
-	if written >= maxDecompressBytes {
-		return newUnpackMaxDecompressLimitReachedError(written, maxDecompressBytes)
+	dstFile, err := CreateAtomicFile(dst, &AtomicFileOptions{
+		Perm:          stat.Mode().Perm(),
+		PreserveMode:  false,
+		PreserveOwner: false,
+	})
+	if err != nil {
+		return CloneMethodUnknown, fmt.Errorf("create dst: %w", err)
 	}
 
-	return nil
-}
-
-func PackGZ(dst io.Writer, reader io.Reader) error {
-	gzWriter := gzip.NewWriter(dst)
-
 	defer func() {
-		if err := gzWriter.Close(); err != nil {
+		if err := dstFile.Close(); err != nil {
 			zerr.Wrap(err).WithField(
-				zap.String("gz_writer", gzWriter.Name),
-			).LogError(libzap.Logger(), "gz writer close failed")
+				zap.String("dst", dst),
+			).LogError(libzap.Logger(), "dst file close failed")
 		}
 	}()
 
-	if _, err := io.Copy(gzWriter, reader); err != nil {
-		return fmt.Errorf("copy: %w", err)
+	method, err := copyData(dstFile.File, srcFile, stat.Size())
+	if err != nil {
+		return method, zerr.Wrap(err, zap.Stringer("clone_method", method))
 	}
 
-	return nil
+	if err := dstFile.Commit(); err != nil {
+		return method, fmt.Errorf("commit dst: %w", err)
+	}
+
+	return method, nil
+}
+
+// UnpackXZ decompresses reader into dst without limits, use UnpackWithConfig to bound the output.
+func UnpackXZ(dst io.Writer, reader io.Reader) error {
+	return UnpackWithConfig(dst, reader, &xzCodec{}, &UnpackConfig{
+		MaxDecompressBytes: 0,
+		MaxRatio:           0,
+	})
+}
+
+func UnpackGZ(dst io.Writer, reader io.Reader, maxDecompressBytes int64) error {
+	return Unpack(dst, reader, &gzCodec{}, maxDecompressBytes)
+}
+
+func PackGZ(dst io.Writer, reader io.Reader) error {
+	return Pack(dst, reader, &gzCodec{}, DefaultLevel)
 }
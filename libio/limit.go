@@ -0,0 +1,80 @@
+package libio
+
+import (
+	"io"
+)
+
+// ratioGraceBytes is the output size below which the ratio limit is not enforced,
+// codec headers and read ahead make the ratio of short streams meaningless.
+const ratioGraceBytes = 1 << 20
+
+// CountingReader counts the bytes read from the underlying reader.
+type CountingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func NewCountingReader(reader io.Reader) *CountingReader {
+	return &CountingReader{
+		reader: reader,
+		count:  0,
+	}
+}
+
+func (r *CountingReader) Read(buff []byte) (int, error) {
+	n, err := r.reader.Read(buff)
+	r.count += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+// Count returns the number of bytes read so far.
+func (r *CountingReader) Count() int64 {
+	return r.count
+}
+
+// LimitReader caps the output of a decompressor by size and by ratio to the compressed input.
+// The output is cut at MaxDecompressBytes and an UnpackMaxDecompressLimitReachedError is returned.
+type LimitReader struct {
+	reader     io.Reader
+	compressed *CountingReader
+	cfg        UnpackConfig
+	written    int64
+}
+
+// NewLimitReader limits reader with cfg, compressed counts the decompressor input and may be nil
+// to disable the ratio limit. Zero limits are disabled.
+func NewLimitReader(reader io.Reader, compressed *CountingReader, cfg *UnpackConfig) *LimitReader {
+	return &LimitReader{
+		reader:     reader,
+		compressed: compressed,
+		cfg:        *cfg,
+		written:    0,
+	}
+}
+
+func (r *LimitReader) Read(buff []byte) (int, error) {
+	n, err := r.reader.Read(buff)
+	total := r.written + int64(n)
+
+	if maxBytes := r.cfg.MaxDecompressBytes; maxBytes > 0 && total > maxBytes {
+		allowed := maxBytes - r.written
+		r.written = maxBytes
+
+		return int(allowed), newUnpackMaxDecompressLimitReachedError(total, maxBytes)
+	}
+
+	r.written = total
+
+	if maxRatio := r.cfg.MaxRatio; maxRatio > 0 && r.compressed != nil && total > ratioGraceBytes &&
+		total > r.compressed.Count()*maxRatio {
+		return n, newUnpackMaxDecompressRatioReachedError(total, r.compressed.Count(), maxRatio)
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+// Written returns the number of decompressed bytes returned so far.
+func (r *LimitReader) Written() int64 {
+	return r.written
+}
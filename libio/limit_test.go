@@ -0,0 +1,44 @@
+package libio_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+)
+
+func TestUnpackLimits(t *testing.T) {
+	t.Parallel()
+
+	zeros := make([]byte, 4<<20)
+
+	for _, name := range []string{"gz", "xz"} {
+		codec, err := libio.CodecByName(name)
+		checkError(t, err)
+
+		var packed bytes.Buffer
+
+		checkError(t, libio.Pack(&packed, bytes.NewReader(zeros), codec, libio.DefaultLevel))
+
+		for limit, cfg := range map[string]*libio.UnpackConfig{
+			"size":  {MaxDecompressBytes: 1 << 20, MaxRatio: 0},
+			"ratio": {MaxDecompressBytes: 0, MaxRatio: 100},
+		} {
+			err := libio.UnpackWithConfig(io.Discard, bytes.NewReader(packed.Bytes()), codec, cfg)
+
+			var limitErr *libio.UnpackMaxDecompressLimitReachedError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("%s %s limit not enforced: %v", name, limit, err)
+			}
+
+			if (limitErr.MaxRatio > 0) != (limit == "ratio") {
+				t.Fatalf("%s %s limit error: %+v", name, limit, limitErr)
+			}
+		}
+
+		unlimited := &libio.UnpackConfig{MaxDecompressBytes: 0, MaxRatio: 0}
+		checkError(t, libio.UnpackWithConfig(io.Discard, bytes.NewReader(packed.Bytes()), codec, unlimited))
+	}
+}
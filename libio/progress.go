@@ -0,0 +1,199 @@
+package libio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultProgressInterval is the reporting interval used when Options.ProgressInterval is not set.
+const DefaultProgressInterval = time.Second
+
+// Progress is a snapshot of a running operation. BytesIn counts the bytes read from the source
+// and BytesOut the bytes written to the destination, Rate and ETA are computed from BytesIn.
+// Total is the expected BytesIn, ETA is 0 when it is unknown. The last snapshot of an operation
+// has Done set, also when the operation failed.
+type Progress struct {
+	BytesIn  int64
+	BytesOut int64
+	Total    int64
+	Elapsed  time.Duration
+	Rate     float64
+	ETA      time.Duration
+	Done     bool
+}
+
+// ProgressFunc receives progress snapshots, it is called from the goroutine doing the io
+// and must not block.
+type ProgressFunc func(progress Progress)
+
+// Options are the optional hooks of libio operations, a nil Options disables them.
+// Hashes computes the digests of the bytes read and written by the operation.
+type Options struct {
+	Progress         ProgressFunc
+	ProgressInterval time.Duration
+	Total            int64
+	RateLimiter      *RateLimiter
+	Hashes           *StreamHashes
+}
+
+// ProgressChan returns a ProgressFunc sending snapshots to progressCh, snapshots are dropped
+// while the channel is full except the final one, which blocks until it is received.
+func ProgressChan(progressCh chan<- Progress) ProgressFunc {
+	return func(progress Progress) {
+		if progress.Done {
+			progressCh <- progress
+			return
+		}
+
+		select {
+		case progressCh <- progress:
+		default:
+		}
+	}
+}
+
+// NewProgressLogger returns a ProgressFunc logging snapshots with logger at info level.
+func NewProgressLogger(logger *zap.Logger, message string) ProgressFunc {
+	return func(progress Progress) {
+		fields := []zap.Field{
+			zap.Int64("bytes_in", progress.BytesIn),
+			zap.Int64("bytes_out", progress.BytesOut),
+			zap.Duration("elapsed", progress.Elapsed),
+			zap.String("rate", fmt.Sprintf("%.1f MiB/s", progress.Rate/mib)),
+			zap.Bool("done", progress.Done),
+		}
+
+		if progress.Total > 0 {
+			fields = append(
+				fields,
+				zap.Int64("total", progress.Total),
+				zap.Duration("eta", progress.ETA),
+			)
+		}
+
+		logger.Info(message, fields...)
+	}
+}
+
+// CopyWithOptions is io.Copy reporting progress through opts.
+func CopyWithOptions(dst io.Writer, reader io.Reader, opts *Options) (int64, error) {
+	return CopyContext(context.Background(), dst, reader, opts)
+}
+
+type progressTracker struct {
+	fn       ProgressFunc
+	interval time.Duration
+	total    int64
+	start    time.Time
+	in       atomic.Int64
+	out      atomic.Int64
+	mu       sync.Mutex
+	last     time.Time
+}
+
+// newProgressTracker returns nil when opts does not request progress.
+func newProgressTracker(opts *Options) *progressTracker {
+	if opts == nil || opts.Progress == nil {
+		return nil
+	}
+
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	now := time.Now()
+
+	return &progressTracker{
+		fn:       opts.Progress,
+		interval: interval,
+		total:    opts.Total,
+		start:    now,
+		in:       atomic.Int64{},
+		out:      atomic.Int64{},
+		mu:       sync.Mutex{},
+		last:     now,
+	}
+}
+
+func (t *progressTracker) reader(reader io.Reader) io.Reader {
+	return &progressReader{reader: reader, tracker: t}
+}
+
+func (t *progressTracker) writer(writer io.Writer) io.Writer {
+	return &progressWriter{writer: writer, tracker: t}
+}
+
+func (t *progressTracker) tick() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.last) < t.interval {
+		return
+	}
+
+	t.last = now
+	t.fn(t.snapshot(now, false))
+}
+
+func (t *progressTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.fn(t.snapshot(time.Now(), true))
+}
+
+func (t *progressTracker) snapshot(now time.Time, done bool) Progress {
+	progress := Progress{
+		BytesIn:  t.in.Load(),
+		BytesOut: t.out.Load(),
+		Total:    t.total,
+		Elapsed:  now.Sub(t.start),
+		Rate:     0,
+		ETA:      0,
+		Done:     done,
+	}
+
+	if seconds := progress.Elapsed.Seconds(); seconds > 0 {
+		progress.Rate = float64(progress.BytesIn) / seconds
+	}
+
+	if remaining := progress.Total - progress.BytesIn; remaining > 0 && progress.Rate > 0 && !done {
+		progress.ETA = time.Duration(float64(remaining) / progress.Rate * float64(time.Second))
+	}
+
+	return progress
+}
+
+type progressReader struct {
+	reader  io.Reader
+	tracker *progressTracker
+}
+
+func (r *progressReader) Read(buff []byte) (int, error) {
+	n, err := r.reader.Read(buff)
+	r.tracker.in.Add(int64(n))
+	r.tracker.tick()
+
+	return n, err //nolint:wrapcheck
+}
+
+type progressWriter struct {
+	writer  io.Writer
+	tracker *progressTracker
+}
+
+func (w *progressWriter) Write(buff []byte) (int, error) {
+	n, err := w.writer.Write(buff)
+	w.tracker.out.Add(int64(n))
+	w.tracker.tick()
+
+	return n, err //nolint:wrapcheck
+}
@@ -0,0 +1,70 @@
+package libio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libio"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCopyWithOptions(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("progress"), 64<<10)
+	progressCh := make(chan libio.Progress, 1)
+	errCh := make(chan error, 1)
+
+	var written int64
+
+	go func() {
+		var err error
+
+		written, err = libio.CopyWithOptions(io.Discard, bytes.NewReader(data), &libio.Options{
+			Progress:         libio.ProgressChan(progressCh),
+			ProgressInterval: time.Nanosecond,
+			Total:            int64(len(data)),
+		})
+		errCh <- err
+	}()
+
+	var last libio.Progress
+
+	for progress := range progressCh {
+		last = progress
+		if progress.Done {
+			break
+		}
+	}
+
+	checkError(t, <-errCh)
+
+	if written != int64(len(data)) || last.BytesIn != written || last.BytesOut != written || last.ETA != 0 {
+		t.Fatalf("last progress: %+v", last)
+	}
+}
+
+func TestProgressLogger(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	data := bytes.Repeat([]byte("progress"), 1024)
+
+	codec, err := libio.CodecByName("gz")
+	checkError(t, err)
+
+	checkError(t, libio.PackWithOptions(io.Discard, bytes.NewReader(data), codec, libio.DefaultLevel, &libio.Options{
+		Progress:         libio.NewProgressLogger(zap.New(core), "pack"),
+		ProgressInterval: time.Hour,
+		Total:            0,
+	}))
+
+	entries := logs.FilterMessage("pack").AllUntimed()
+	if len(entries) != 1 || entries[0].ContextMap()["bytes_in"] != int64(len(data)) {
+		t.Fatalf("progress log: %+v", entries)
+	}
+}
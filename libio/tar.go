@@ -0,0 +1,185 @@
+package libio
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+// WalkTar calls fn for every member of a plain, gz or xz compressed tar archive.
+// maxDecompressBytes caps the decompressed size of a compressed archive, 0 disables the limit.
+func WalkTar(reader io.Reader, fn ArchiveWalkFunc, maxDecompressBytes int64) error {
+	tarReader, codec, closer, err := openTar(reader, maxDecompressBytes)
+	if err != nil {
+		return err
+	}
+
+	defer closeTar(closer, codec)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("tar next: %w", err)
+		}
+
+		if err := fn(tarMember(header), tarReader); err != nil {
+			return zerr.Wrap(err, zap.String("member", header.Name))
+		}
+	}
+}
+
+// RewriteTar copies a plain, gz or xz compressed tar archive to dst passing the regular members through fn.
+// Headers and member order are kept, a compressed archive is recompressed with the same codec.
+// It returns the number of rewritten members.
+func RewriteTar(dst io.Writer, reader io.Reader, fn RewriteFunc, maxDecompressBytes int64) (int, error) {
+	tarReader, codec, closer, err := openTar(reader, maxDecompressBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	defer closeTar(closer, codec)
+
+	output := dst
+
+	var codecWriter io.WriteCloser
+
+	if codec != nil {
+		if codecWriter, err = codec.NewWriter(dst, DefaultLevel); err != nil {
+			return 0, zerr.Wrap(fmt.Errorf("new writer: %w", err), zap.String("codec", codec.Name()))
+		}
+
+		output = codecWriter
+	}
+
+	rewritten, err := rewriteTar(tar.NewWriter(output), tarReader, fn)
+	if err != nil {
+		if codecWriter != nil {
+			_ = codecWriter.Close()
+		}
+
+		return rewritten, err
+	}
+
+	if codecWriter != nil {
+		if err := codecWriter.Close(); err != nil {
+			return rewritten, fmt.Errorf("close writer: %w", err)
+		}
+	}
+
+	return rewritten, nil
+}
+
+func rewriteTar(tarWriter *tar.Writer, tarReader *tar.Reader, fn RewriteFunc) (int, error) {
+	var rewritten int
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return rewritten, fmt.Errorf("tar next: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return rewritten, zerr.Wrap(fmt.Errorf("write header: %w", err), zap.String("member", header.Name))
+			}
+
+			if _, err := io.Copy(tarWriter, tarReader); err != nil {
+				return rewritten, zerr.Wrap(fmt.Errorf("copy member: %w", err), zap.String("member", header.Name))
+			}
+
+			continue
+		}
+
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return rewritten, zerr.Wrap(fmt.Errorf("read member: %w", err), zap.String("member", header.Name))
+		}
+
+		newData, err := fn(tarMember(header), data)
+		if err != nil {
+			return rewritten, zerr.Wrap(fmt.Errorf("rewrite: %w", err), zap.String("member", header.Name))
+		}
+
+		if newData != nil {
+			data = newData
+			header.Size = int64(len(data))
+			rewritten++
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return rewritten, zerr.Wrap(fmt.Errorf("write header: %w", err), zap.String("member", header.Name))
+		}
+
+		if _, err := tarWriter.Write(data); err != nil {
+			return rewritten, zerr.Wrap(fmt.Errorf("write member: %w", err), zap.String("member", header.Name))
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return rewritten, fmt.Errorf("close tar: %w", err)
+	}
+
+	return rewritten, nil
+}
+
+// openTar detects the compression of the archive by its magic, codec is nil for a plain archive.
+func openTar(reader io.Reader, maxDecompressBytes int64) (*tar.Reader, Codec, io.Closer, error) {
+	buffered := bufio.NewReader(reader)
+
+	header, err := buffered.Peek(MaxMagicSize())
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, nil, fmt.Errorf("peek magic: %w", err)
+	}
+
+	codec, err := DetectCodec(header)
+	if err != nil {
+		return tar.NewReader(buffered), nil, nil, nil //nolint:nilerr
+	}
+
+	codecReader, err := codec.NewReader(buffered)
+	if err != nil {
+		return nil, nil, nil, zerr.Wrap(fmt.Errorf("new reader: %w", err), zap.String("codec", codec.Name()))
+	}
+
+	limited := NewLimitReader(codecReader, nil, &UnpackConfig{
+		MaxDecompressBytes: maxDecompressBytes,
+		MaxRatio:           0,
+	})
+
+	return tar.NewReader(limited), codec, codecReader, nil
+}
+
+func closeTar(closer io.Closer, codec Codec) {
+	if closer == nil {
+		return
+	}
+
+	if err := closer.Close(); err != nil {
+		zerr.Wrap(err).WithField(
+			zap.String("codec", codec.Name()),
+		).LogError(libzap.Logger(), "codec reader close failed")
+	}
+}
+
+func tarMember(header *tar.Header) *ArchiveMember {
+	return &ArchiveMember{
+		Name:    header.Name,
+		Size:    header.Size,
+		Mode:    header.FileInfo().Mode(),
+		ModTime: header.ModTime,
+	}
+}
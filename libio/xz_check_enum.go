@@ -0,0 +1,50 @@
+package libio
+
+import (
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=XZCheckEnum -linecomment -output xz_check_enum_string.go
+type XZCheckEnum int //nolint:recvcheck
+
+const (
+	XZCheckUnknown XZCheckEnum = iota // unknown
+	XZCheckCRC32   XZCheckEnum = iota // crc32
+	XZCheckCRC64   XZCheckEnum = iota // crc64
+)
+
+func (e *XZCheckEnum) SetValue(value string) error {
+	check := XZCheckFromString(value)
+	if check == XZCheckUnknown {
+		return liberrors.NewInvalidStringEntityError("xz_check", value)
+	}
+
+	*e = check
+
+	return nil
+}
+
+func (e XZCheckEnum) MarshalText() ([]byte, error) {
+	if e == XZCheckUnknown {
+		return nil, liberrors.NewInvalidStringEntityError("xz_check", e.String())
+	}
+
+	return []byte(e.String()), nil
+}
+
+func (e *XZCheckEnum) UnmarshalText(text []byte) error {
+	return e.SetValue(string(text))
+}
+
+func XZCheckFromString(value string) XZCheckEnum {
+	switch strings.ToLower(value) {
+	case "crc32":
+		return XZCheckCRC32
+	case "crc64":
+		return XZCheckCRC64
+	default:
+		return XZCheckUnknown
+	}
+}
@@ -0,0 +1,201 @@
+package libio
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+const (
+	zipExtraHeaderSize = 4
+	zipExtraZip64ID    = 0x0001
+)
+
+// WalkZip calls fn for every member of the zip archive in central directory order.
+// maxDecompressBytes caps the total decompressed size of the members, 0 disables the limit.
+func WalkZip(reader io.ReaderAt, size int64, fn ArchiveWalkFunc, maxDecompressBytes int64) error {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return fmt.Errorf("zip reader: %w", err)
+	}
+
+	budget := &zipBudget{maxDecompressBytes: maxDecompressBytes, written: 0}
+
+	for _, file := range zipReader.File {
+		if err := walkZipFile(file, fn, budget); err != nil {
+			return zerr.Wrap(err, zap.String("member", file.Name))
+		}
+	}
+
+	return nil
+}
+
+// RewriteZip copies the zip archive to dst passing the regular members through fn.
+// Untouched members are copied raw, rewritten members keep their header and compression method.
+// It returns the number of rewritten members.
+func RewriteZip(dst io.Writer, reader io.ReaderAt, size int64, fn RewriteFunc, maxDecompressBytes int64) (int, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return 0, fmt.Errorf("zip reader: %w", err)
+	}
+
+	zipWriter := zip.NewWriter(dst)
+	if err := zipWriter.SetComment(zipReader.Comment); err != nil {
+		return 0, fmt.Errorf("set comment: %w", err)
+	}
+
+	budget := &zipBudget{maxDecompressBytes: maxDecompressBytes, written: 0}
+
+	var rewritten int
+
+	for _, file := range zipReader.File {
+		changed, err := rewriteZipFile(zipWriter, file, fn, budget)
+		if err != nil {
+			return rewritten, zerr.Wrap(err, zap.String("member", file.Name))
+		}
+
+		if changed {
+			rewritten++
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return rewritten, fmt.Errorf("close zip: %w", err)
+	}
+
+	return rewritten, nil
+}
+
+// zipBudget shares maxDecompressBytes between the members of an archive.
+type zipBudget struct {
+	maxDecompressBytes int64
+	written            int64
+}
+
+func (b *zipBudget) open(file *zip.File) (io.ReadCloser, *LimitReader, error) {
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open member: %w", err)
+	}
+
+	limited := NewLimitReader(fileReader, nil, &UnpackConfig{
+		MaxDecompressBytes: b.maxDecompressBytes,
+		MaxRatio:           0,
+	})
+	// continue counting from the previous members
+	limited.written = b.written
+
+	return fileReader, limited, nil
+}
+
+func walkZipFile(file *zip.File, fn ArchiveWalkFunc, budget *zipBudget) error {
+	fileReader, limited, err := budget.open(file)
+	if err != nil {
+		return err
+	}
+
+	defer closeZipFile(fileReader, file.Name)
+
+	if err := fn(zipMember(file), limited); err != nil {
+		return err
+	}
+
+	budget.written = limited.Written()
+
+	return nil
+}
+
+func rewriteZipFile(zipWriter *zip.Writer, file *zip.File, fn RewriteFunc, budget *zipBudget) (bool, error) {
+	if !file.Mode().IsRegular() {
+		if err := zipWriter.Copy(file); err != nil {
+			return false, fmt.Errorf("copy member: %w", err)
+		}
+
+		return false, nil
+	}
+
+	fileReader, limited, err := budget.open(file)
+	if err != nil {
+		return false, err
+	}
+
+	defer closeZipFile(fileReader, file.Name)
+
+	data, err := io.ReadAll(limited)
+	if err != nil {
+		return false, fmt.Errorf("read member: %w", err)
+	}
+
+	budget.written = limited.Written()
+
+	newData, err := fn(zipMember(file), data)
+	if err != nil {
+		return false, fmt.Errorf("rewrite: %w", err)
+	}
+
+	if newData == nil {
+		if err := zipWriter.Copy(file); err != nil {
+			return false, fmt.Errorf("copy member: %w", err)
+		}
+
+		return false, nil
+	}
+
+	header := file.FileHeader
+	header.Extra = stripZip64Extra(header.Extra)
+
+	memberWriter, err := zipWriter.CreateHeader(&header)
+	if err != nil {
+		return false, fmt.Errorf("create header: %w", err)
+	}
+
+	if _, err := memberWriter.Write(newData); err != nil {
+		return false, fmt.Errorf("write member: %w", err)
+	}
+
+	return true, nil
+}
+
+func closeZipFile(fileReader io.Closer, name string) {
+	if err := fileReader.Close(); err != nil {
+		zerr.Wrap(err).WithField(
+			zap.String("member", name),
+		).LogError(libzap.Logger(), "zip member close failed")
+	}
+}
+
+// stripZip64Extra drops the zip64 extra field holding the old sizes, zip.Writer adds a new one when needed.
+func stripZip64Extra(extra []byte) []byte {
+	stripped := make([]byte, 0, len(extra))
+
+	for len(extra) >= zipExtraHeaderSize {
+		fieldID := binary.LittleEndian.Uint16(extra)
+		fieldSize := zipExtraHeaderSize + int(binary.LittleEndian.Uint16(extra[2:]))
+
+		if fieldSize > len(extra) {
+			break
+		}
+
+		if fieldID != zipExtraZip64ID {
+			stripped = append(stripped, extra[:fieldSize]...)
+		}
+
+		extra = extra[fieldSize:]
+	}
+
+	return stripped
+}
+
+func zipMember(file *zip.File) *ArchiveMember {
+	return &ArchiveMember{
+		Name:    file.Name,
+		Size:    int64(file.UncompressedSize64), //nolint:gosec
+		Mode:    file.Mode(),
+		ModTime: file.Modified,
+	}
+}
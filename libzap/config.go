@@ -1,10 +1,75 @@
 package libzap
 
-import "go.uber.org/zap/zapcore"
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
 
 type OutputFileConfig struct {
-	Dir        string `yaml:"dir"        env:"DIR"         env-default:"logs"       env-description:"Set the output dir for logs."`
-	TimeLayout string `yaml:"timeLayout" env:"TIME_LAYOUT" env-default:"2006-01-02" env-description:"Set the time layout for file name (appID-time.log)."`
+	Dir        string        `yaml:"dir"        env:"DIR"         env-default:"logs"       env-description:"Set the output dir for logs."`
+	TimeLayout string        `yaml:"timeLayout" env:"TIME_LAYOUT" env-default:"2006-01-02" env-description:"Set the time layout for file name (appID-time.log), the file is rotated when the formatted time changes."`
+	MaxSize    int           `yaml:"maxSize"    env:"MAX_SIZE"    env-default:"0"          env-description:"Rotate the file once it exceeds the size in megabytes, 0 disables size rotation."`
+	MaxBackups int           `yaml:"maxBackups" env:"MAX_BACKUPS" env-default:"0"          env-description:"Keep at most this number of rotated files, 0 keeps all of them."`
+	MaxAge     time.Duration `yaml:"maxAge"     env:"MAX_AGE"     env-default:"0"          env-description:"Remove rotated files older than the age (e.g. 720h), 0 keeps all of them."`
+	Compress   bool          `yaml:"compress"   env:"COMPRESS"    env-default:"false"      env-description:"Compress rotated files with gzip."`
+}
+
+type SyslogConfig struct {
+	Network  string `yaml:"network"  env:"NETWORK"  env-default:"unix"     env-description:"Set the syslog network (unix, udp, tcp), unix is a datagram socket."`
+	Address  string `yaml:"address"  env:"ADDRESS"  env-default:"/dev/log" env-description:"Set the syslog address, a socket path for unix or host:port."`
+	Facility string `yaml:"facility" env:"FACILITY" env-default:"user"     env-description:"Set the syslog facility (kern, user, daemon, local0-local7...)."`
+	AppName  string `yaml:"appName"  env:"APP_NAME" env-default:""         env-description:"Override the syslog app name, the app id by default."`
+}
+
+type JournaldConfig struct {
+	Socket     string `yaml:"socket"     env:"SOCKET"     env-default:"/run/systemd/journal/socket" env-description:"Set the journald native protocol socket."`
+	Identifier string `yaml:"identifier" env:"IDENTIFIER" env-default:""                            env-description:"Override the SYSLOG_IDENTIFIER journal field, the app id by default."`
+}
+
+type OTLPConfig struct {
+	Endpoint      string            `yaml:"endpoint"      env:"ENDPOINT"       env-default:"http://localhost:4318/v1/logs" env-description:"Set the OTLP/HTTP logs endpoint, the records are sent as JSON."`
+	Headers       map[string]string `yaml:"headers"       env:"HEADERS"        env-default:""                              env-description:"Set the headers of the export requests (key:value,key:value)."`
+	ServiceName   string            `yaml:"serviceName"   env:"SERVICE_NAME"   env-default:""                              env-description:"Override the service.name resource attribute, the app id by default."`
+	BatchSize     int               `yaml:"batchSize"     env:"BATCH_SIZE"     env-default:"512"                           env-description:"Export once the batch has this number of records."`
+	QueueSize     int               `yaml:"queueSize"     env:"QUEUE_SIZE"     env-default:"2048"                          env-description:"Set the number of records waiting for the export, records past it are dropped."`
+	FlushInterval time.Duration     `yaml:"flushInterval" env:"FLUSH_INTERVAL" env-default:"5s"                            env-description:"Export the pending records at this interval."`
+	Timeout       time.Duration     `yaml:"timeout"       env:"TIMEOUT"        env-default:"10s"                           env-description:"Set the export request timeout."`
+}
+
+type RedactConfig struct {
+	Keys     []string `yaml:"keys"     env:"KEYS"     env-default:""    env-description:"Mask the fields with these keys, case insensitive (password,token)."`
+	Patterns []string `yaml:"patterns" env:"PATTERNS" env-default:""    env-description:"Mask the parts of the messages and string fields matching these regular expressions, separated by ; in the env." env-separator:";"`
+	Mask     string   `yaml:"mask"     env:"MASK"     env-default:"***" env-description:"Set the replacement of the masked values."`
+}
+
+// SamplingConfig overrides the preset sampling, production samples 100 entries then every 100th per second,
+// development does not sample. Setting any of Initial, Thereafter and Tick replaces the preset sampling.
+type SamplingConfig struct {
+	Disabled   bool          `yaml:"disabled"   env:"DISABLED"   env-default:"false" env-description:"Disable the sampling, including the production preset one."`
+	Initial    int           `yaml:"initial"    env:"INITIAL"    env-default:"0"     env-description:"Set the number of entries with the same level and message logged per tick, 0 keeps the preset sampling when Thereafter and Tick are 0 too."`
+	Thereafter int           `yaml:"thereafter" env:"THEREAFTER" env-default:"0"     env-description:"Log every Thereafter entry once Initial entries were logged in the tick, 0 drops them."`
+	Tick       time.Duration `yaml:"tick"       env:"TICK"       env-default:"0"     env-description:"Set the sampling interval, 0 means 1s."`
+}
+
+type RateLimitConfig struct {
+	Burst    int           `yaml:"burst"    env:"BURST"    env-default:"0"  env-description:"Log at most Burst entries with the same level and message per interval, 0 disables the limit. The number of suppressed entries is logged once the interval ends."`
+	Interval time.Duration `yaml:"interval" env:"INTERVAL" env-default:"1s" env-description:"Set the rate limit interval."`
+}
+
+// SinkConfig is an output with its own encoder and level, empty fields inherit the preset values.
+type SinkConfig struct {
+	Output        OutputEnum       `yaml:"output"        env-description:"Set the sink output (stdout, stderr, file, syslog, journald, otlp)."`
+	Level         string           `yaml:"level"         env-description:"Set the minimum level of the sink, the logger level still applies."`
+	Encoding      EncodingEnum     `yaml:"encoding"      env-description:"Set the sink encoder (console, json)."`
+	TimeKey       string           `yaml:"timeKey"       env-description:"Set the key used for time log entry, - omits the entry."`
+	LevelKey      string           `yaml:"levelKey"      env-description:"Set the key used for level log entry, - omits the entry."`
+	NameKey       string           `yaml:"nameKey"       env-description:"Set the key used for name log entry, - omits the entry."`
+	CallerKey     string           `yaml:"callerKey"     env-description:"Set the key used for caller log entry, - omits the entry."`
+	FunctionKey   string           `yaml:"functionKey"   env-description:"Set the key used for function log entry, - omits the entry."`
+	MessageKey    string           `yaml:"messageKey"    env-description:"Set the key used for message log entry, - omits the entry."`
+	StacktraceKey string           `yaml:"stacktraceKey" env-description:"Set the key used for stack trace log entry, - omits the entry."`
+	OutputFile    OutputFileConfig `yaml:"outputFile"    env-description:"Set the file output of the sink, the preset one is used without dir. Syslog, journald and otlp sinks use the preset config."`
 }
 
 type PresetConfig struct {
@@ -16,7 +81,7 @@ type PresetConfig struct {
 	TimeLayout        string              `yaml:"timeLayout"        env:"TIME_LAYOUT"         env-default:""           env-description:"Override the time layout."`
 	DurationEncoder   string              `yaml:"durationEncoder"   env:"DURATION_ENCODER"    env-default:"string"     env-description:"Set the duration encoder."`
 	CallerEncoder     string              `yaml:"callerEncoder"     env:"CALLER_ENCODER"      env-default:""           env-description:"Override the caller encoder."`
-	Outputs           map[OutputEnum]bool `yaml:"outputs"           env:"OUTPUTS"             env-default:""           env-description:"The outputs override (stdout, stderr, file)."`
+	Outputs           map[OutputEnum]bool `yaml:"outputs"           env:"OUTPUTS"             env-default:""           env-description:"The outputs override (stdout, stderr, file, syslog, journald, otlp)."`
 	JSONTimeKey       string              `yaml:"jsonTimeKey"       env:"JSON_TIME_KEY"       env-default:"ts"         env-description:"Set the key used for time log entry. If key is empty, the entry is omitted."`
 	JSONLevelKey      string              `yaml:"jsonLevelKey"      env:"JSON_LEVEL_KEY"      env-default:"level"      env-description:"Set the key used for level log entry. If key is empty, the entry is omitted."`
 	JSONNameKey       string              `yaml:"jsonNameKey"       env:"JSON_NAME_KEY"       env-default:"logger"     env-description:"Set the key used for name log entry. If key is empty, the entry is omitted."`
@@ -31,6 +96,14 @@ type PresetConfig struct {
 	ConsoleSeparator  string              `yaml:"consoleSeparator"  env:"CONSOLE_SEPARATOR"   env-default:""           env-description:"Override the separator used for console encoding."`
 
 	OutputFile OutputFileConfig `yaml:"outputFile" env-prefix:"OUTPUT_FILE__"`
+	Syslog     SyslogConfig     `yaml:"syslog"     env-prefix:"SYSLOG__"`
+	Journald   JournaldConfig   `yaml:"journald"   env-prefix:"JOURNALD__"`
+	OTLP       OTLPConfig       `yaml:"otlp"       env-prefix:"OTLP__"`
+	Redact     RedactConfig     `yaml:"redact"     env-prefix:"REDACT__"`
+	Sampling   SamplingConfig   `yaml:"sampling"   env-prefix:"SAMPLING__"`
+	RateLimit  RateLimitConfig  `yaml:"rateLimit"  env-prefix:"RATE_LIMIT__"`
+	// Sinks replace Outputs, every sink is written through its own encoder.
+	Sinks []SinkConfig `yaml:"sinks" env-description:"The sinks with their own level, encoding and keys."`
 }
 
 type Config struct {
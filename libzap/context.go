@@ -4,6 +4,7 @@
 	"context"
 
 	"github.com/grinderz/go-libs/libctx"
+	"github.com/grinderz/go-libs/libzap/zfield"
 	"go.uber.org/zap"
 )
 
@@ -12,15 +13,21 @@
 	defaultContextLogger = zap.NewNop()      //nolint:gochecknoglobals
 )
 
+// FromContext returns the logger of the context with the trace fields of the span in the context.
 func FromContext(ctx context.Context) *zap.Logger {
 	log, ok := ctx.Value(contextKey).(*zap.Logger)
 	if !ok {
 		return defaultContextLogger
 	}
 
+	if fields := zfield.TraceFields(ctx); fields != nil {
+		return log.With(fields...)
+	}
+
 	return log
 }
 
+// ToContext stores the logger in the context, it should not carry trace fields, FromContext adds them.
 func ToContext(ctx context.Context, logger *zap.Logger) context.Context {
 	return context.WithValue(ctx, contextKey, logger)
 }
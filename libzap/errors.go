@@ -5,4 +5,6 @@
 var (
 	ErrEmptyConfig          = errors.New("zap config is empty")
 	ErrLoggerAlreadyDefined = errors.New("logger already defined")
+	ErrUnknownOutput        = errors.New("unknown output")
+	ErrUnknownEncoding      = errors.New("unknown encoding")
 )
@@ -0,0 +1,126 @@
+package libzap
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// globalState is the global logger, it is replaced as a whole under globalMu and read without locking.
+// close releases what Setup opened for the logger, previous is the state Replace swapped out.
+type globalState struct {
+	logger   *zap.Logger
+	levels   *Levels
+	close    func() error
+	previous *globalState
+	defined  bool
+}
+
+var (
+	globalMu sync.Mutex                  //nolint:gochecknoglobals
+	_global  atomic.Pointer[globalState] //nolint:gochecknoglobals
+)
+
+func init() { //nolint:gochecknoinits
+	_global.Store(newNopState())
+}
+
+// Logger returns the global logger, a no-op logger until Setup, SetupFromLogger or Replace.
+func Logger() *zap.Logger {
+	return _global.Load().logger
+}
+
+// LoggerLevels returns the levels of the global logger, nil unless it was created by Setup.
+func LoggerLevels() *Levels {
+	return _global.Load().levels
+}
+
+func Setup(appID string, cfg *Config) error {
+	if cfg == nil {
+		return ErrEmptyConfig
+	}
+
+	if _global.Load().defined {
+		return ErrLoggerAlreadyDefined
+	}
+
+	zp, levels, closeLogger, err := NewWithClose(appID, cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	state := &globalState{logger: zp, levels: levels, close: closeLogger, previous: nil, defined: true}
+	if err := define(state); err != nil {
+		return errors.Join(err, closeLogger())
+	}
+
+	return nil
+}
+
+func SetupFromLogger(logger *zap.Logger) error {
+	return define(&globalState{logger: logger, levels: nil, close: nil, previous: nil, defined: true})
+}
+
+// SyncAndClose syncs the global logger, closes the files and connections Setup opened for it, also when
+// it was swapped out by Replace, and resets it to a no-op logger that Setup can define again.
+func SyncAndClose() error {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	var errs []error
+
+	for state := _global.Swap(newNopState()); state != nil; state = state.previous {
+		if err := state.logger.Sync(); !isSyncUnsupported(err) {
+			errs = append(errs, err)
+		}
+
+		if state.close != nil {
+			errs = append(errs, state.close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Replace sets the global logger even if it is already defined and returns a func restoring the previous one.
+// Loggers derived from the global one before the call, e.g. by With, keep writing to the previous logger.
+// The previous logger stays open to be restored, SyncAndClose closes it with the current one.
+func Replace(logger *zap.Logger) func() {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	previous := _global.Load()
+	_global.Store(&globalState{logger: logger, levels: nil, close: nil, previous: previous, defined: true})
+
+	return func() {
+		globalMu.Lock()
+		defer globalMu.Unlock()
+
+		_global.Store(previous)
+	}
+}
+
+// isSyncUnsupported reports the error fsync returns for terminals and pipes, e.g. stdout and stderr.
+func isSyncUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
+
+func newNopState() *globalState {
+	return &globalState{logger: zap.NewNop(), levels: nil, close: nil, previous: nil, defined: false}
+}
+
+func define(state *globalState) error {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if _global.Load().defined {
+		return ErrLoggerAlreadyDefined
+	}
+
+	_global.Store(state)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package libzap_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/ztest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+//nolint:paralleltest // the global logger is shared
+func TestReplace(t *testing.T) {
+	if libzap.Logger() == nil {
+		t.Fatal("no default logger")
+	}
+
+	libzap.Logger().With(libzap.FieldPkg("default")).Info("dropped")
+
+	outer, outerLogs := ztest.New(zapcore.DebugLevel)
+	restore := libzap.Replace(outer)
+
+	if err := libzap.SetupFromLogger(zap.NewNop()); !errors.Is(err, libzap.ErrLoggerAlreadyDefined) {
+		t.Fatalf("setup after replace: %v", err)
+	}
+
+	t.Run("observed", func(t *testing.T) {
+		logs := ztest.Replace(t, zapcore.WarnLevel)
+
+		var wg sync.WaitGroup
+
+		for range 8 {
+			wg.Go(func() {
+				libzap.Logger().Warn("concurrent")
+				libzap.Logger().Info("filtered")
+			})
+		}
+
+		wg.Wait()
+
+		if logs.FilterMessage("concurrent").Len() != 8 || logs.Len() != 8 {
+			t.Fatalf("observed: %d", logs.Len())
+		}
+	})
+
+	libzap.Logger().Info("outer")
+	restore()
+
+	libzap.Logger().Info("dropped")
+
+	if outerLogs.Len() != 1 || outerLogs.All()[0].Message != "outer" {
+		t.Fatalf("outer logs: %v", outerLogs.All())
+	}
+}
+
+//nolint:paralleltest // the global logger is shared
+func TestSyncAndClose(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Encoding:       libzap.EncodingJSON,
+			JSONMessageKey: "msg",
+			Sinks: []libzap.SinkConfig{
+				{
+					Output: libzap.OutputFile,
+					OutputFile: libzap.OutputFileConfig{
+						Dir:        dir,
+						TimeLayout: "2006-01-02",
+						MaxSize:    0,
+						MaxBackups: 0,
+						MaxAge:     0,
+						Compress:   false,
+					},
+				},
+			},
+		},
+	}
+
+	checkError(t, libzap.Setup("service", cfg))
+
+	libzap.Logger().Info("setup")
+
+	// the replaced logger is closed with the current one
+	libzap.Replace(zap.NewNop())
+	checkError(t, libzap.SyncAndClose())
+
+	libzap.Logger().Info("dropped")
+
+	data, err := os.ReadFile(filepath.Join(dir, "service-"+time.Now().Format("2006-01-02")+".log"))
+	checkError(t, err)
+
+	if !strings.Contains(string(data), `"msg":"setup"`) || strings.Contains(string(data), "dropped") {
+		t.Fatalf("log file: %q", data)
+	}
+
+	checkError(t, libzap.Setup("service", cfg))
+	checkError(t, libzap.SyncAndClose())
+}
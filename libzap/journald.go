@@ -0,0 +1,192 @@
+package libzap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type journaldCore struct {
+	zapcore.LevelEnabler
+
+	conn       *net.UnixConn
+	identifier string
+	fields     []zapcore.Field
+}
+
+// NewJournaldCore sends the entries to journald with its native protocol. The zap fields become journal
+// fields with upper case names, e.g. request_id is sent as REQUEST_ID, objects and arrays as JSON.
+func NewJournaldCore( //nolint:ireturn
+	enabler zapcore.LevelEnabler,
+	appID string,
+	cfg *JournaldConfig,
+) (zapcore.Core, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: cfg.Socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("dial journald: %w", err)
+	}
+
+	identifier := cfg.Identifier
+	if identifier == "" {
+		identifier = appID
+	}
+
+	return &journaldCore{
+		LevelEnabler: enabler,
+		conn:         conn,
+		identifier:   identifier,
+		fields:       nil,
+	}, nil
+}
+
+func (c *journaldCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+
+	return &clone
+}
+
+func (c *journaldCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *journaldCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	var msg bytes.Buffer
+
+	appendJournalField(&msg, "MESSAGE", entry.Message)
+	appendJournalField(&msg, "PRIORITY", strconv.Itoa(syslogSeverity(entry.Level)))
+	appendJournalField(&msg, "SYSLOG_IDENTIFIER", c.identifier)
+
+	if entry.LoggerName != "" {
+		appendJournalField(&msg, "LOGGER_NAME", entry.LoggerName)
+	}
+
+	if entry.Caller.Defined {
+		appendJournalField(&msg, "CODE_FILE", entry.Caller.File)
+		appendJournalField(&msg, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		appendJournalField(&msg, "CODE_FUNC", entry.Caller.Function)
+	}
+
+	if entry.Stack != "" {
+		appendJournalField(&msg, "STACKTRACE", entry.Stack)
+	}
+
+	keys := make([]string, 0, len(encoder.Fields))
+	for key := range encoder.Fields {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		appendJournalField(&msg, journalFieldName(key), journalFieldValue(encoder.Fields[key]))
+	}
+
+	if err := c.send(msg.Bytes()); err != nil {
+		return fmt.Errorf("write journald: %w", err)
+	}
+
+	return nil
+}
+
+func (c *journaldCore) send(msg []byte) error {
+	_, err := c.conn.Write(msg)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		// entries past the datagram size are passed in a sealed memory file
+		return sendJournalFile(c.conn, msg)
+	}
+
+	return err //nolint:wrapcheck
+}
+
+func (c *journaldCore) Sync() error {
+	return nil
+}
+
+// Close closes the journald socket.
+func (c *journaldCore) Close() error {
+	return c.conn.Close() //nolint:wrapcheck
+}
+
+// appendJournalField writes NAME=value, values with a new line use the binary length prefixed form.
+func appendJournalField(msg *bytes.Buffer, name, value string) {
+	msg.WriteString(name)
+
+	if !strings.Contains(value, "\n") {
+		msg.WriteByte('=')
+		msg.WriteString(value)
+		msg.WriteByte('\n')
+
+		return
+	}
+
+	msg.WriteByte('\n')
+	_ = binary.Write(msg, binary.LittleEndian, uint64(len(value)))
+	msg.WriteString(value)
+	msg.WriteByte('\n')
+}
+
+// journalFieldName maps a field key to a journal field name: upper case letters, digits and underscores,
+// starting with a letter.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_")
+	if name == "" || name[0] <= '9' {
+		name = "F_" + name
+	}
+
+	return name
+}
+
+func journalFieldValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case fmt.Stringer:
+		return value.String()
+	case map[string]any, []any:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,35 @@
+package libzap
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendJournalFile passes msg to journald in a sealed memfd, the way sd_journal_send does for large entries.
+func sendJournalFile(conn *net.UnixConn, msg []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("create memfd: %w", err)
+	}
+
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+
+	if _, err := file.Write(msg); err != nil {
+		return fmt.Errorf("write memfd: %w", err)
+	}
+
+	seals := unix.F_SEAL_SEAL | unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+		return fmt.Errorf("seal memfd: %w", err)
+	}
+
+	if _, _, err := conn.WriteMsgUnix(nil, unix.UnixRights(fd), nil); err != nil {
+		return fmt.Errorf("send memfd: %w", err)
+	}
+
+	return nil
+}
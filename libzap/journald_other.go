@@ -0,0 +1,14 @@
+//go:build !linux
+
+package libzap
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// sendJournalFile fails, journald and memfd exist on linux only.
+func sendJournalFile(_ *net.UnixConn, msg []byte) error {
+	return fmt.Errorf("journal entry of %d bytes: %w", len(msg), syscall.EMSGSIZE)
+}
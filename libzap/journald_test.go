@@ -0,0 +1,82 @@
+package libzap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJournaldCore(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	checkError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	core, err := libzap.NewJournaldCore(zapcore.InfoLevel, "service", &libzap.JournaldConfig{
+		Socket:     socket,
+		Identifier: "",
+	})
+	checkError(t, err)
+
+	logger := zap.New(core).Named("db").With(zap.String("request-id", "r1"))
+	logger.Debug("disabled")
+	logger.Error("query failed", zap.Error(errors.New("line one\nline two")), zap.Int("2fa", 1))
+
+	fields := parseJournal(t, []byte(readPacket(t, conn)))
+
+	for name, value := range map[string]string{
+		"MESSAGE":           "query failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "service",
+		"LOGGER_NAME":       "db",
+		"REQUEST_ID":        "r1",
+		"ERROR":             "line one\nline two",
+		"F_2FA":             "1",
+	} {
+		if fields[name] != value {
+			t.Fatalf("%s: %q != %q (%v)", name, fields[name], value, fields)
+		}
+	}
+}
+
+// parseJournal decodes the native protocol: NAME=value lines or NAME, a little endian length and the value.
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	fields := map[string]string{}
+
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+
+		if name, value, ok := strings.Cut(string(line), "="); ok {
+			fields[name] = value
+			data = rest
+
+			continue
+		}
+
+		if len(rest) < 8 {
+			t.Fatalf("truncated field %s", line)
+		}
+
+		size := binary.LittleEndian.Uint64(rest)
+		fields[string(line)] = string(rest[8 : 8+size])
+		data = rest[8+size+1:]
+	}
+
+	return fields
+}
@@ -0,0 +1,202 @@
+package libzap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the level of a logger built by New and the level overrides of its named loggers.
+// An override applies to the logger with its name and to the loggers named below it (name.child).
+type Levels struct {
+	level     zap.AtomicLevel
+	mu        sync.RWMutex
+	overrides map[string]zapcore.Level
+}
+
+func NewLevels(level zap.AtomicLevel) *Levels {
+	return &Levels{
+		level:     level,
+		mu:        sync.RWMutex{},
+		overrides: map[string]zapcore.Level{},
+	}
+}
+
+// AtomicLevel returns the level shared by all loggers without an override.
+func (l *Levels) AtomicLevel() zap.AtomicLevel {
+	return l.level
+}
+
+func (l *Levels) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+func (l *Levels) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// SetOverride sets the level of the named logger and its children.
+func (l *Levels) SetOverride(name string, level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides[name] = level
+}
+
+func (l *Levels) RemoveOverride(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.overrides, name)
+}
+
+func (l *Levels) Overrides() map[string]zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return maps.Clone(l.overrides)
+}
+
+// LevelFor returns the level of the named logger, the longest matching override wins.
+func (l *Levels) LevelFor(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for prefix := name; len(l.overrides) > 0; {
+		if level, ok := l.overrides[prefix]; ok {
+			return level
+		}
+
+		ind := strings.LastIndexByte(prefix, '.')
+		if ind < 0 {
+			break
+		}
+
+		prefix = prefix[:ind]
+	}
+
+	return l.level.Level()
+}
+
+// Enabled reports whether some logger logs at level.
+func (l *Levels) Enabled(level zapcore.Level) bool {
+	if l.level.Enabled(level) {
+		return true
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, override := range l.overrides {
+		if override.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WrapCore applies the levels to core, use it with zap.WrapCore.
+func (l *Levels) WrapCore(core zapcore.Core) zapcore.Core { //nolint:ireturn
+	return &levelsCore{Core: core, levels: l}
+}
+
+type levelsPayload struct {
+	Level     *zapcore.Level           `json:"level,omitempty"`
+	Logger    string                   `json:"logger,omitempty"`
+	Overrides map[string]zapcore.Level `json:"overrides,omitempty"`
+}
+
+// ServeHTTP reports the levels on GET and changes them on PUT.
+// A PUT body {"level":"debug"} sets the level, {"logger":"name","level":"debug"} sets an override
+// and {"logger":"name"} removes it.
+func (l *Levels) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := l.update(request); err != nil {
+			writeLevelsError(writer, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		writeLevelsError(writer, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", request.Method))
+		return
+	}
+
+	level := l.Level()
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(&levelsPayload{
+		Level:     &level,
+		Logger:    "",
+		Overrides: l.Overrides(),
+	})
+}
+
+func (l *Levels) update(request *http.Request) error {
+	var payload levelsPayload
+
+	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("decode body: %w", err)
+	}
+
+	switch {
+	case payload.Logger == "" && payload.Level == nil:
+		return errors.New("level or logger required") //nolint:err113
+	case payload.Logger == "":
+		l.SetLevel(*payload.Level)
+	case payload.Level == nil:
+		l.RemoveOverride(payload.Logger)
+	default:
+		l.SetOverride(payload.Logger, *payload.Level)
+	}
+
+	return nil
+}
+
+func writeLevelsError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+}
+
+// levelsCore filters entries by the level of their logger name before the wrapped core checks them,
+// so the wrapped core must be enabled for every level an override may request.
+type levelsCore struct {
+	zapcore.Core
+
+	levels *Levels
+}
+
+func (c *levelsCore) Enabled(level zapcore.Level) bool {
+	return c.levels.Enabled(level)
+}
+
+func (c *levelsCore) Level() zapcore.Level {
+	level := c.levels.Level()
+
+	for _, override := range c.levels.Overrides() {
+		level = min(level, override)
+	}
+
+	return level
+}
+
+func (c *levelsCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	return &levelsCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelsCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.LevelFor(entry.LoggerName).Enabled(entry.Level) {
+		return checked
+	}
+
+	return c.Core.Check(entry, checked)
+}
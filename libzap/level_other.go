@@ -0,0 +1,8 @@
+//go:build !unix
+
+package libzap
+
+import "context"
+
+// CycleOnSignals does nothing, SIGUSR1 and SIGUSR2 exist on unix only.
+func (l *Levels) CycleOnSignals(_ context.Context) {}
@@ -0,0 +1,107 @@
+package libzap_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelsOverrides(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	levels := libzap.NewLevels(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	logger := zap.New(levels.WrapCore(core))
+
+	levels.SetOverride("db", zapcore.DebugLevel)
+	levels.SetOverride("db.pool", zapcore.ErrorLevel)
+
+	logger.Debug("root")
+	logger.Named("db").Debug("db")
+	logger.Named("db").Named("query").Debug("db.query")
+	logger.Named("db").Named("pool").Warn("db.pool")
+	logger.Named("dbx").Debug("dbx")
+
+	if got := messages(logs); got != "db,db.query" {
+		t.Fatalf("messages: %s", got)
+	}
+
+	levels.RemoveOverride("db")
+	logger.Named("db").Debug("removed")
+
+	if logs.Len() != 2 || logger.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatalf("override not removed: %d", logs.Len())
+	}
+}
+
+func TestLevelsHandler(t *testing.T) {
+	t.Parallel()
+
+	levels := libzap.NewLevels(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	server := httptest.NewServer(levels)
+
+	t.Cleanup(server.Close)
+
+	for _, body := range []string{`{"level":"warn"}`, `{"logger":"http","level":"debug"}`} {
+		checkStatus(t, server.URL, http.MethodPut, body, http.StatusOK)
+	}
+
+	checkStatus(t, server.URL, http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest)
+	checkStatus(t, server.URL, http.MethodPost, "", http.StatusMethodNotAllowed)
+
+	response := checkStatus(t, server.URL, http.MethodGet, "", http.StatusOK)
+
+	var payload struct {
+		Level     string            `json:"level"`
+		Overrides map[string]string `json:"overrides"`
+	}
+
+	checkError(t, json.NewDecoder(response.Body).Decode(&payload))
+	checkError(t, response.Body.Close())
+
+	if payload.Level != "warn" || payload.Overrides["http"] != "debug" {
+		t.Fatalf("levels: %+v", payload)
+	}
+
+	if levels.LevelFor("http.api") != zapcore.DebugLevel {
+		t.Fatalf("http.api level: %s", levels.LevelFor("http.api"))
+	}
+}
+
+func checkStatus(t *testing.T, url, method, body string, status int) *http.Response {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), method, url, strings.NewReader(body))
+	checkError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	checkError(t, err)
+
+	if response.StatusCode != status {
+		t.Fatalf("%s %s: %d != %d", method, body, response.StatusCode, status)
+	}
+
+	if method != http.MethodGet {
+		checkError(t, response.Body.Close())
+	}
+
+	return response
+}
+
+func messages(logs *observer.ObservedLogs) string {
+	entries := logs.All()
+	result := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		result = append(result, entry.Message)
+	}
+
+	return strings.Join(result, ",")
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package libzap
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// CycleOnSignals lowers the level on SIGUSR1 (more verbose) and raises it on SIGUSR2 (less verbose),
+// between debug and fatal, until ctx is done. Overrides are kept as is.
+func (l *Levels) CycleOnSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				level := l.Level()
+
+				if sig == syscall.SIGUSR1 {
+					level = max(level-1, zapcore.DebugLevel)
+				} else {
+					level = min(level+1, zapcore.FatalLevel)
+				}
+
+				l.SetLevel(level)
+			}
+		}
+	}()
+}
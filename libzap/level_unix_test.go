@@ -0,0 +1,46 @@
+//go:build unix
+
+package libzap_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelsCycleOnSignals(t *testing.T) {
+	t.Parallel()
+
+	levels := libzap.NewLevels(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+
+	levels.CycleOnSignals(ctx)
+
+	for _, step := range []struct {
+		sig   syscall.Signal
+		level zapcore.Level
+	}{
+		{syscall.SIGUSR1, zapcore.DebugLevel},
+		{syscall.SIGUSR2, zapcore.InfoLevel},
+		{syscall.SIGUSR2, zapcore.WarnLevel},
+	} {
+		checkError(t, syscall.Kill(syscall.Getpid(), step.sig))
+
+		// signals are handled asynchronously, wait for the change of this step
+		deadline := time.Now().Add(time.Second)
+		for levels.Level() != step.level && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		if levels.Level() != step.level {
+			t.Fatalf("%s level: %s != %s", step.sig, levels.Level(), step.level)
+		}
+	}
+}
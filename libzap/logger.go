@@ -1,28 +1,48 @@
 package libzap
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/grinderz/go-libs/libzap/zerr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var _logger *zap.Logger //nolint:gochecknoglobals
+func New(appID string, cfg *Config, runtimeCfg *RuntimeConfig) (*zap.Logger, error) {
+	logger, _, err := NewWithLevels(appID, cfg, runtimeCfg)
 
-func Logger() *zap.Logger {
-	return _logger
+	return logger, err
 }
 
-func New(appID string, cfg *Config, runtimeCfg *RuntimeConfig) (*zap.Logger, error) {
+// NewWithLevels is New that also returns the levels to change the logger level at runtime.
+func NewWithLevels(appID string, cfg *Config, runtimeCfg *RuntimeConfig) (*zap.Logger, *Levels, error) {
+	logger, levels, _, err := NewWithClose(appID, cfg, runtimeCfg)
+
+	return logger, levels, err
+}
+
+// NewWithClose is NewWithLevels that also returns the func closing the files and connections opened
+// for the sinks and outputs, the logger must not be used after it. New and NewWithLevels leave them open.
+func NewWithClose( //nolint:nonamedreturns
+	appID string,
+	cfg *Config,
+	runtimeCfg *RuntimeConfig,
+) (_ *zap.Logger, _ *Levels, _ func() error, err error) {
 	var (
 		zcfg      zap.Config
 		presetCfg *PresetConfig
+		opened    closers
 	)
 
+	defer func() {
+		if err != nil {
+			_ = opened.close()
+		}
+	}()
+
 	switch cfg.Preset {
 	case PresetDevelopment:
 		zcfg = zap.NewDevelopmentConfig()
@@ -45,68 +65,84 @@ func New(appID string, cfg *Config, runtimeCfg *RuntimeConfig) (*zap.Logger, err
 	setKeys(presetCfg, &zcfg)
 
 	if err := setLevelEncoder(presetCfg, &zcfg); err != nil {
-		return nil, fmt.Errorf("set level encoder: %w", err)
+		return nil, nil, nil, fmt.Errorf("set level encoder: %w", err)
 	}
 
 	if err := setTimeEncoder(presetCfg, &zcfg); err != nil {
-		return nil, fmt.Errorf("set time encoder: %w", err)
+		return nil, nil, nil, fmt.Errorf("set time encoder: %w", err)
 	}
 
 	if err := setDurationEncoder(presetCfg, &zcfg); err != nil {
-		return nil, fmt.Errorf("set duration encoder: %w", err)
+		return nil, nil, nil, fmt.Errorf("set duration encoder: %w", err)
 	}
 
 	if err := setCallerEncoder(presetCfg, &zcfg); err != nil {
-		return nil, fmt.Errorf("set caller encoder: %w", err)
+		return nil, nil, nil, fmt.Errorf("set caller encoder: %w", err)
 	}
 
-	if err := setOutputs(appID, presetCfg, &zcfg, runtimeCfg); err != nil {
-		return nil, fmt.Errorf("set outputs encoder: %w", err)
+	redactor, err := NewRedactor(&presetCfg.Redact)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("new redactor: %w", err)
+	}
+
+	outputs, err := setOutputs(appID, presetCfg, &zcfg, runtimeCfg, redactor, &opened)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("set outputs encoder: %w", err)
 	}
 
 	if runtimeCfg != nil {
 		zcfg.Level = zap.NewAtomicLevelAt(runtimeCfg.Level)
 	} else {
 		if err := setLevel(presetCfg, &zcfg); err != nil {
-			return nil, fmt.Errorf("set level: %w", err)
+			return nil, nil, nil, fmt.Errorf("set level: %w", err)
 		}
 	}
 
-	logger, err := zcfg.Build()
+	// the core is enabled for every level, levels filters the entries by logger name first
+	levels := NewLevels(zcfg.Level)
+	zcfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	sinks, err := setSinks(appID, presetCfg, &zcfg, runtimeCfg, redactor, &opened)
 	if err != nil {
-		return nil, fmt.Errorf("build: %w", err)
+		return nil, nil, nil, fmt.Errorf("set sinks: %w", err)
 	}
 
-	return logger, nil
-}
-
-func Setup(appID string, cfg *Config) error {
-	if _logger != nil {
-		return ErrLoggerAlreadyDefined
-	}
+	options := setSampling(presetCfg, &zcfg, &opened)
 
-	if cfg == nil {
-		return ErrEmptyConfig
+	// prepended in reverse: the redactor wraps the built core, then the sinks and outputs change it,
+	// the sampling and levels options wrap the result
+	for _, option := range []zap.Option{outputs, sinks, zap.WrapCore(redactor.WrapCore)} {
+		if option != nil {
+			options = append([]zap.Option{option}, options...)
+		}
 	}
 
-	zp, err := New(appID, cfg, nil)
+	logger, err := zcfg.Build(append(options, zap.WrapCore(levels.WrapCore))...)
 	if err != nil {
-		return err
+		return nil, nil, nil, fmt.Errorf("build: %w", err)
 	}
 
-	_logger = zp
+	return logger, levels, opened.close, nil
+}
+
+// closers release the files and connections opened for a logger.
+type closers []func() error
 
-	return nil
+func (c *closers) add(closer func() error) {
+	*c = append(*c, closer)
 }
 
-func SetupFromLogger(logger *zap.Logger) error {
-	if _logger != nil {
-		return ErrLoggerAlreadyDefined
+// close runs the closers in reverse order once, later calls return nil.
+func (c *closers) close() error {
+	errs := make([]error, 0, len(*c))
+
+	for ind := len(*c) - 1; ind >= 0; ind-- {
+		errs = append(errs, (*c)[ind]())
 	}
 
-	_logger = logger
+	*c = nil
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func setLevel(presetCfg *PresetConfig, zcfg *zap.Config) error {
@@ -203,12 +239,22 @@ func setCallerEncoder(presetCfg *PresetConfig, zcfg *zap.Config) error {
 	return nil
 }
 
-func setOutputs(appID string, presetCfg *PresetConfig, zcfg *zap.Config, rcfg *RuntimeConfig) error {
-	if len(presetCfg.Outputs) == 0 {
-		return nil
+// setOutputs sets the output paths, syslog, journald and otlp are returned as an option adding their cores
+// and their connections are added to opened.
+func setOutputs(
+	appID string,
+	presetCfg *PresetConfig,
+	zcfg *zap.Config,
+	rcfg *RuntimeConfig,
+	redactor *Redactor,
+	opened *closers,
+) (zap.Option, error) {
+	if len(presetCfg.Outputs) == 0 || len(presetCfg.Sinks) > 0 {
+		return nil, nil //nolint:nilnil
 	}
 
 	outputs := make([]string, 0, len(presetCfg.Outputs))
+	coreOutputs := make([]OutputEnum, 0, len(presetCfg.Outputs))
 	fileEnabled := false
 
 	for output, enabled := range presetCfg.Outputs {
@@ -216,6 +262,11 @@ func setOutputs(appID string, presetCfg *PresetConfig, zcfg *zap.Config, rcfg *R
 			continue
 		}
 
+		if output == OutputSyslog || output == OutputJournald || output == OutputOTLP {
+			coreOutputs = append(coreOutputs, output)
+			continue
+		}
+
 		if output == OutputFile {
 			if rcfg != nil && !rcfg.OutputFileEnabled {
 				continue
@@ -235,49 +286,110 @@ func setOutputs(appID string, presetCfg *PresetConfig, zcfg *zap.Config, rcfg *R
 		zcfg.ErrorOutputPaths = outputs
 	}
 
+	if !fileEnabled && len(coreOutputs) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	encoder, err := newEncoder(presetCfg.Encoding, zcfg.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cores := make([]zapcore.Core, 0, len(coreOutputs)+1)
+
 	if fileEnabled {
-		if err := setFileOutput(appID, presetCfg, zcfg); err != nil {
-			return fmt.Errorf("set file output: %w", err)
+		core, err := newFileOutputCore(appID, presetCfg, encoder.Clone(), opened)
+		if err != nil {
+			return nil, fmt.Errorf("new file output core: %w", err)
+		}
+
+		if core != nil {
+			cores = append(cores, redactor.WrapCore(core))
 		}
 	}
 
-	return nil
+	for _, output := range coreOutputs {
+		core, err := newOutputCore(appID, presetCfg, output, encoder.Clone(), zapcore.DebugLevel, opened)
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("new output core: %w", err),
+				zap.Stringer("output", output),
+			)
+		}
+
+		cores = append(cores, redactor.WrapCore(core))
+	}
+
+	pathsEnabled := len(outputs) > 0 || fileEnabled
+
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if pathsEnabled {
+			return zapcore.NewTee(append([]zapcore.Core{core}, cores...)...)
+		}
+
+		return zapcore.NewTee(cores...)
+	}), nil
 }
 
-func setFileOutput(appID string, presetCfg *PresetConfig, zcfg *zap.Config) error {
-	var dir string
+// newFileOutputCore writes to a rotating file closed with opened, it is nil when the dir is ignored.
+func newFileOutputCore( //nolint:ireturn
+	appID string,
+	presetCfg *PresetConfig,
+	encoder zapcore.Encoder,
+	opened *closers,
+) (zapcore.Core, error) {
+	dir, err := outputFileDir(&presetCfg.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dir) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	rotatingFile, err := NewRotatingFile(dir, appID, &presetCfg.OutputFile)
+	if err != nil {
+		return nil, zerr.Wrap(
+			fmt.Errorf("new rotating file: %w", err),
+			zap.String("dir", dir),
+		)
+	}
+
+	opened.add(rotatingFile.Close)
 
-	if filepath.IsLocal(presetCfg.OutputFile.Dir) {
+	return zapcore.NewCore(encoder, rotatingFile, zapcore.DebugLevel), nil
+}
+
+// outputFileDir resolves a local dir against the working directory, other relative dirs are ignored.
+func outputFileDir(cfg *OutputFileConfig) (string, error) {
+	if filepath.IsLocal(cfg.Dir) {
 		cwd, err := os.Getwd()
 		if err != nil {
-			return fmt.Errorf("detect working directory: %w", err)
+			return "", fmt.Errorf("detect working directory: %w", err)
 		}
 
-		dir = filepath.Join(cwd, presetCfg.OutputFile.Dir)
-	} else if filepath.IsAbs(presetCfg.OutputFile.Dir) {
-		dir = presetCfg.OutputFile.Dir
+		return filepath.Join(cwd, cfg.Dir), nil
 	}
 
-	if len(dir) > 0 {
-		runTS := time.Now().Format(presetCfg.OutputFile.TimeLayout)
-		location := filepath.Join(dir, fmt.Sprintf("%s-%s.log", appID, runTS))
-
-		if len(location) > 0 {
-			zcfg.OutputPaths = append(zcfg.OutputPaths, location)
-		}
+	if filepath.IsAbs(cfg.Dir) {
+		return cfg.Dir, nil
 	}
 
-	return nil
+	return "", nil
 }
 
 func setKeys(presetCfg *PresetConfig, zcfg *zap.Config) {
 	if presetCfg.Encoding == EncodingJSON {
-		zcfg.EncoderConfig.TimeKey = presetCfg.JSONTimeKey
-		zcfg.EncoderConfig.MessageKey = presetCfg.JSONMessageKey
-		zcfg.EncoderConfig.StacktraceKey = presetCfg.JSONStacktraceKey
-		zcfg.EncoderConfig.CallerKey = presetCfg.JSONCallerKey
-		zcfg.EncoderConfig.LevelKey = presetCfg.JSONLevelKey
-		zcfg.EncoderConfig.FunctionKey = presetCfg.JSONFunctionKey
-		zcfg.EncoderConfig.NameKey = presetCfg.JSONNameKey
+		setJSONKeys(presetCfg, &zcfg.EncoderConfig)
 	}
 }
+
+func setJSONKeys(presetCfg *PresetConfig, encoderCfg *zapcore.EncoderConfig) {
+	encoderCfg.TimeKey = presetCfg.JSONTimeKey
+	encoderCfg.MessageKey = presetCfg.JSONMessageKey
+	encoderCfg.StacktraceKey = presetCfg.JSONStacktraceKey
+	encoderCfg.CallerKey = presetCfg.JSONCallerKey
+	encoderCfg.LevelKey = presetCfg.JSONLevelKey
+	encoderCfg.FunctionKey = presetCfg.JSONFunctionKey
+	encoderCfg.NameKey = presetCfg.JSONNameKey
+}
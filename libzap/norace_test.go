@@ -0,0 +1,5 @@
+//go:build !race
+
+package libzap_test
+
+const raceEnabled = false
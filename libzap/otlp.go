@@ -0,0 +1,488 @@
+package libzap
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap/zfield"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	otlpScopeName            = "github.com/grinderz/go-libs/libzap"
+	otlpDefaultBatchSize     = 512
+	otlpDefaultQueueSize     = 2048
+	otlpDefaultFlushInterval = 5 * time.Second
+	otlpDefaultTimeout       = 10 * time.Second
+)
+
+var (
+	ErrOTLPQueueFull      = errors.New("otlp queue full, record dropped")
+	ErrOTLPExporterClosed = errors.New("otlp exporter closed")
+)
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValueList struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string           `json:"stringValue,omitempty"`
+	BoolValue   *bool             `json:"boolValue,omitempty"`
+	IntValue    *string           `json:"intValue,omitempty"`
+	DoubleValue *float64          `json:"doubleValue,omitempty"`
+	BytesValue  *string           `json:"bytesValue,omitempty"`
+	ArrayValue  *otlpArrayValue   `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKeyValueList `json:"kvlistValue,omitempty"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID        string         `json:"traceId,omitempty"`
+	SpanID         string         `json:"spanId,omitempty"`
+
+	scope string
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+// otlpExporter batches the records in the background and posts them to an OTLP/HTTP JSON endpoint.
+type otlpExporter struct {
+	client      *http.Client
+	cfg         OTLPConfig
+	serviceName string
+	records     chan otlpLogRecord
+	flushCh     chan chan error
+	closeOnce   sync.Once
+	mu          sync.RWMutex
+	closed      bool
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+type otlpCore struct {
+	zapcore.LevelEnabler
+
+	exporter *otlpExporter
+	fields   []zapcore.Field
+}
+
+// NewOTLPCore exports the entries as OTLP log records, the trace_id and span_id fields added by
+// zfield.TraceFields become the record trace context. Write fails when the export queue is full.
+// Non positive sizes and durations of cfg take the defaults of OTLPConfig.
+func NewOTLPCore(enabler zapcore.LevelEnabler, appID string, cfg *OTLPConfig) zapcore.Core { //nolint:ireturn
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = appID
+	}
+
+	exporterCfg := *cfg
+	exporterCfg.BatchSize = positiveOr(cfg.BatchSize, otlpDefaultBatchSize)
+	exporterCfg.QueueSize = positiveOr(cfg.QueueSize, otlpDefaultQueueSize)
+	exporterCfg.FlushInterval = positiveOr(cfg.FlushInterval, otlpDefaultFlushInterval)
+	exporterCfg.Timeout = positiveOr(cfg.Timeout, otlpDefaultTimeout)
+
+	exporter := &otlpExporter{
+		client:      &http.Client{Timeout: exporterCfg.Timeout}, //nolint:exhaustruct
+		cfg:         exporterCfg,
+		serviceName: serviceName,
+		records:     make(chan otlpLogRecord, exporterCfg.QueueSize),
+		flushCh:     make(chan chan error),
+		closeOnce:   sync.Once{},
+		mu:          sync.RWMutex{},
+		closed:      false,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	go exporter.run()
+
+	return &otlpCore{
+		LevelEnabler: enabler,
+		exporter:     exporter,
+		fields:       nil,
+	}
+}
+
+func positiveOr[T int | time.Duration](value, fallback T) T {
+	if value > 0 {
+		return value
+	}
+
+	return fallback
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+
+	return &clone
+}
+
+func (c *otlpCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *otlpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(entry.Time.UnixNano(), 10),
+		SeverityNumber: otlpSeverity(entry.Level),
+		SeverityText:   entry.Level.CapitalString(),
+		Body:           otlpValue(entry.Message),
+		Attributes:     make([]otlpKeyValue, 0, len(encoder.Fields)),
+		TraceID:        "",
+		SpanID:         "",
+		scope:          entry.LoggerName,
+	}
+
+	record.TraceID, _ = encoder.Fields[zfield.TraceIDKey].(string)
+	record.SpanID, _ = encoder.Fields[zfield.SpanIDKey].(string)
+
+	delete(encoder.Fields, zfield.TraceIDKey)
+	delete(encoder.Fields, zfield.SpanIDKey)
+
+	if entry.Caller.Defined {
+		encoder.Fields["code.file.path"] = entry.Caller.File
+		encoder.Fields["code.line.number"] = int64(entry.Caller.Line)
+		encoder.Fields["code.function.name"] = entry.Caller.Function
+	}
+
+	if entry.Stack != "" {
+		encoder.Fields["code.stacktrace"] = entry.Stack
+	}
+
+	record.Attributes = otlpKeyValues(encoder.Fields)
+
+	return c.exporter.enqueue(record)
+}
+
+// Sync exports the queued records and returns the error of the exports since the previous Sync.
+func (c *otlpCore) Sync() error {
+	return c.exporter.flush()
+}
+
+// Close exports the queued records and stops the exporter.
+func (c *otlpCore) Close() error {
+	var err error
+
+	c.exporter.closeOnce.Do(func() {
+		// No record is enqueued once closed is set, so the final flush exports all of them.
+		c.exporter.mu.Lock()
+		c.exporter.closed = true
+		c.exporter.mu.Unlock()
+
+		err = c.exporter.flush()
+
+		close(c.exporter.done)
+		<-c.exporter.stopped
+	})
+
+	return err
+}
+
+func (e *otlpExporter) enqueue(record otlpLogRecord) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return ErrOTLPExporterClosed
+	}
+
+	select {
+	case e.records <- record:
+		return nil
+	default:
+		return ErrOTLPQueueFull
+	}
+}
+
+func (e *otlpExporter) flush() error {
+	result := make(chan error, 1)
+
+	select {
+	case e.flushCh <- result:
+		return <-result
+	case <-e.done:
+		return ErrOTLPExporterClosed
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, e.cfg.BatchSize)
+
+	var exportErr error
+
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		exportErr = errors.Join(exportErr, e.export(batch))
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record := <-e.records:
+			if batch = append(batch, record); len(batch) >= e.cfg.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case result := <-e.flushCh:
+			for drained := false; !drained; {
+				select {
+				case record := <-e.records:
+					batch = append(batch, record)
+				default:
+					drained = true
+				}
+			}
+
+			export()
+
+			result <- exportErr
+			exportErr = nil
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *otlpExporter) export(records []otlpLogRecord) error {
+	scopes := make([]otlpScopeLogs, 0, 1)
+	scopeInd := map[string]int{}
+
+	for _, record := range records {
+		ind, ok := scopeInd[record.scope]
+		if !ok {
+			ind = len(scopes)
+			scopeInd[record.scope] = ind
+
+			name := record.scope
+			if name == "" {
+				name = otlpScopeName
+			}
+
+			scopes = append(scopes, otlpScopeLogs{Scope: otlpScope{Name: name}, LogRecords: nil})
+		}
+
+		scopes[ind].LogRecords = append(scopes[ind].LogRecords, record)
+	}
+
+	body, err := json.Marshal(&otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValue(e.serviceName)}},
+			},
+			ScopeLogs: scopes,
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal otlp logs: %w", err)
+	}
+
+	// the client timeout bounds the request, the export outlives the logging calls
+	ctx := context.Background()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new otlp request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for key, value := range e.cfg.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := e.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("post otlp logs: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post otlp logs: %w", newOTLPStatusError(response.StatusCode, len(records)))
+	}
+
+	return nil
+}
+
+type otlpStatusError struct {
+	status  int
+	records int
+}
+
+func newOTLPStatusError(status, records int) *otlpStatusError {
+	return &otlpStatusError{status: status, records: records}
+}
+
+func (e *otlpStatusError) Error() string {
+	return fmt.Sprintf("status %d, %d records lost", e.status, e.records)
+}
+
+// otlpSeverity maps the zap levels to the OTLP severity numbers.
+func otlpSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 5 //nolint:mnd
+	case zapcore.InfoLevel:
+		return 9 //nolint:mnd
+	case zapcore.WarnLevel:
+		return 13 //nolint:mnd
+	case zapcore.ErrorLevel:
+		return 17 //nolint:mnd
+	case zapcore.DPanicLevel:
+		return 19 //nolint:mnd
+	case zapcore.PanicLevel, zapcore.FatalLevel:
+		return 21 //nolint:mnd
+	case zapcore.InvalidLevel:
+		fallthrough
+	default:
+		return 0
+	}
+}
+
+func otlpKeyValues(fields map[string]any) []otlpKeyValue {
+	values := make([]otlpKeyValue, 0, len(fields))
+
+	for key, value := range fields {
+		values = append(values, otlpKeyValue{Key: key, Value: otlpValue(value)})
+	}
+
+	return values
+}
+
+func otlpValue(value any) otlpAnyValue {
+	var result otlpAnyValue
+
+	switch value := value.(type) {
+	case string:
+		result.StringValue = &value
+	case bool:
+		result.BoolValue = &value
+	case int:
+		result.IntValue = otlpInt(int64(value))
+	case int8:
+		result.IntValue = otlpInt(int64(value))
+	case int16:
+		result.IntValue = otlpInt(int64(value))
+	case int32:
+		result.IntValue = otlpInt(int64(value))
+	case int64:
+		result.IntValue = otlpInt(value)
+	case time.Duration:
+		result.IntValue = otlpInt(int64(value))
+	case uint:
+		result.IntValue = otlpUint(uint64(value))
+	case uint8:
+		result.IntValue = otlpUint(uint64(value))
+	case uint16:
+		result.IntValue = otlpUint(uint64(value))
+	case uint32:
+		result.IntValue = otlpUint(uint64(value))
+	case uint64:
+		result.IntValue = otlpUint(value)
+	case uintptr:
+		result.IntValue = otlpUint(uint64(value))
+	case float64:
+		result.DoubleValue = &value
+	case float32:
+		doubleValue := float64(value)
+		result.DoubleValue = &doubleValue
+	case []byte:
+		bytesValue := base64.StdEncoding.EncodeToString(value)
+		result.BytesValue = &bytesValue
+	case []any:
+		array := &otlpArrayValue{Values: make([]otlpAnyValue, 0, len(value))}
+
+		for _, item := range value {
+			array.Values = append(array.Values, otlpValue(item))
+		}
+
+		result.ArrayValue = array
+	case map[string]any:
+		result.KvlistValue = &otlpKeyValueList{Values: otlpKeyValues(value)}
+	default:
+		stringValue := fmt.Sprint(value)
+		result.StringValue = &stringValue
+	}
+
+	return result
+}
+
+func otlpInt(value int64) *string {
+	intValue := strconv.FormatInt(value, 10)
+
+	return &intValue
+}
+
+func otlpUint(value uint64) *string {
+	intValue := strconv.FormatUint(value, 10)
+
+	return &intValue
+}
@@ -0,0 +1,324 @@
+package libzap_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zfield"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const (
+	testTraceID = "0102030405060708090a0b0c0d0e0f10"
+	testSpanID  = "0102030405060708"
+)
+
+func TestTraceFields(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := libzap.ToContext(traceContext(t), zap.New(core))
+
+	libzap.FromContext(ctx).Info("from context")
+	zfield.WithContext(zfield.Context(ctx, zap.Int("id", 1)), zap.New(core)).Info("with context")
+	libzap.FromContext(libzap.ToContext(t.Context(), zap.New(core))).Info("without span")
+
+	for ind, entry := range logs.All() {
+		fields := entry.ContextMap()
+		if ind < 2 && (fields[zfield.TraceIDKey] != testTraceID || fields[zfield.SpanIDKey] != testSpanID) {
+			t.Fatalf("%s fields: %v", entry.Message, fields)
+		}
+
+		if ind == 2 && len(fields) != 0 {
+			t.Fatalf("%s fields: %v", entry.Message, fields)
+		}
+	}
+}
+
+func TestOTLPCore(t *testing.T) {
+	t.Parallel()
+
+	requests := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var payload map[string]any
+
+		if request.URL.Path != "/v1/logs" || request.Header.Get("Authorization") != "token" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		requests <- payload
+	}))
+
+	t.Cleanup(server.Close)
+
+	core := libzap.NewOTLPCore(zapcore.InfoLevel, "service", &libzap.OTLPConfig{
+		Endpoint:      server.URL + "/v1/logs",
+		Headers:       map[string]string{"Authorization": "token"},
+		ServiceName:   "",
+		BatchSize:     16,
+		QueueSize:     16,
+		FlushInterval: time.Hour,
+		Timeout:       5 * time.Second,
+	})
+
+	logger := zap.New(core).Named("api")
+	zfield.WithContext(traceContext(t), logger).Warn("exported", zap.Int("status", 502))
+	checkError(t, logger.Sync())
+
+	var request struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope      map[string]any `json:"scope"`
+				LogRecords []struct {
+					SeverityNumber int              `json:"severityNumber"`
+					Body           map[string]any   `json:"body"`
+					Attributes     []map[string]any `json:"attributes"`
+					TraceID        string           `json:"traceId"`
+					SpanID         string           `json:"spanId"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+
+	data, err := json.Marshal(<-requests)
+	checkError(t, err)
+	checkError(t, json.Unmarshal(data, &request))
+
+	resource := request.ResourceLogs[0]
+	scope := resource.ScopeLogs[0]
+	record := scope.LogRecords[0]
+
+	serviceName, _ := resource.Resource.Attributes[0]["value"].(map[string]any)
+	if serviceName["stringValue"] != "service" || scope.Scope["name"] != "api" {
+		t.Fatalf("resource: %s", data)
+	}
+
+	if record.SeverityNumber != 13 || record.Body["stringValue"] != "exported" || len(record.Attributes) != 1 {
+		t.Fatalf("record: %s", data)
+	}
+
+	if record.TraceID != testTraceID || record.SpanID != testSpanID {
+		t.Fatalf("record trace: %s", data)
+	}
+
+	if closer, ok := core.(interface{ Close() error }); ok {
+		checkError(t, closer.Close())
+	}
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel}, nil); err == nil { //nolint:exhaustruct
+		t.Fatal("write after close accepted")
+	}
+}
+
+func TestOTLPCoreExportError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	t.Cleanup(server.Close)
+
+	logger := zap.New(libzap.NewOTLPCore(zapcore.InfoLevel, "service", &libzap.OTLPConfig{
+		Endpoint:      server.URL,
+		Headers:       nil,
+		ServiceName:   "",
+		BatchSize:     16,
+		QueueSize:     16,
+		FlushInterval: time.Hour,
+		Timeout:       5 * time.Second,
+	}))
+
+	logger.Info("lost")
+
+	if err := logger.Sync(); err == nil {
+		t.Fatal("export error not reported")
+	}
+
+	checkError(t, logger.Sync())
+}
+
+func TestOTLPCoreIntAttributes(t *testing.T) {
+	t.Parallel()
+
+	attributes := make(chan []map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+		var payload struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []struct {
+						Attributes []map[string]any `json:"attributes"`
+					} `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&payload); err == nil {
+			attributes <- payload.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Attributes
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	cfg := &libzap.OTLPConfig{Endpoint: server.URL} //nolint:exhaustruct
+	logger := zap.New(libzap.NewOTLPCore(zapcore.InfoLevel, "service", cfg))
+
+	logger.Info("ints",
+		zap.Int32("int32", -32),
+		zap.Uint32("uint32", 32),
+		zap.Int16("int16", -16),
+		zap.Uint("uint", 64),
+		zap.Duration("duration", time.Second),
+	)
+	checkError(t, logger.Sync())
+
+	expected := map[string]string{
+		"int32":    "-32",
+		"uint32":   "32",
+		"int16":    "-16",
+		"uint":     "64",
+		"duration": "1000000000",
+	}
+
+	got := <-attributes
+	if len(got) != len(expected) {
+		t.Fatalf("attributes: %v", got)
+	}
+
+	for _, attribute := range got {
+		key, _ := attribute["key"].(string)
+		value, _ := attribute["value"].(map[string]any)
+
+		if value["intValue"] != expected[key] {
+			t.Fatalf("attribute %s: %v", key, value)
+		}
+	}
+}
+
+func TestOTLPCoreCloseRace(t *testing.T) {
+	t.Parallel()
+
+	var exported atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+		var payload struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []json.RawMessage `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&payload); err == nil {
+			for _, scope := range payload.ResourceLogs[0].ScopeLogs {
+				exported.Add(int64(len(scope.LogRecords)))
+			}
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	core := libzap.NewOTLPCore(zapcore.InfoLevel, "service", &libzap.OTLPConfig{
+		Endpoint:      server.URL,
+		Headers:       nil,
+		ServiceName:   "",
+		BatchSize:     1024,
+		QueueSize:     1024,
+		FlushInterval: time.Hour,
+		Timeout:       5 * time.Second,
+	})
+
+	var (
+		accepted atomic.Int64
+		group    sync.WaitGroup
+	)
+
+	// every write accepted before or during Close must be exported by the final flush
+	for range 4 {
+		group.Go(func() {
+			for range 200 {
+				if core.Write(zapcore.Entry{Level: zapcore.InfoLevel}, nil) == nil { //nolint:exhaustruct
+					accepted.Add(1)
+				}
+			}
+		})
+	}
+
+	if closer, ok := core.(interface{ Close() error }); ok {
+		checkError(t, closer.Close())
+	}
+
+	group.Wait()
+
+	if accepted.Load() != exported.Load() {
+		t.Fatalf("accepted %d records, exported %d", accepted.Load(), exported.Load())
+	}
+}
+
+func traceContext(t *testing.T) context.Context {
+	t.Helper()
+
+	traceID, err := trace.TraceIDFromHex(testTraceID)
+	checkError(t, err)
+
+	spanID, err := trace.SpanIDFromHex(testSpanID)
+	checkError(t, err)
+
+	return trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestOTLPCoreDefaults(t *testing.T) {
+	t.Parallel()
+
+	exports := make(chan int, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+		var payload struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []json.RawMessage `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+
+		if err := json.NewDecoder(request.Body).Decode(&payload); err == nil {
+			exports <- len(payload.ResourceLogs[0].ScopeLogs[0].LogRecords)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	// zero sizes and intervals fall back to the defaults instead of panicking or exporting per record
+	cfg := &libzap.OTLPConfig{Endpoint: server.URL} //nolint:exhaustruct
+	logger := zap.New(libzap.NewOTLPCore(zapcore.InfoLevel, "service", cfg))
+
+	logger.Info("first")
+	logger.Info("second")
+	checkError(t, logger.Sync())
+
+	if records := <-exports; records != 2 || len(exports) != 0 {
+		t.Fatalf("exported %d records in %d requests", records, len(exports)+1)
+	}
+}
@@ -10,10 +10,13 @@
 type OutputEnum int //nolint:recvcheck
 
 const (
-	OutputUnknown OutputEnum = iota // unknown
-	OutputStdout  OutputEnum = iota // stdout
-	OutputStderr  OutputEnum = iota // stderr
-	OutputFile    OutputEnum = iota // file
+	OutputUnknown  OutputEnum = iota // unknown
+	OutputStdout   OutputEnum = iota // stdout
+	OutputStderr   OutputEnum = iota // stderr
+	OutputFile     OutputEnum = iota // file
+	OutputSyslog   OutputEnum = iota // syslog
+	OutputJournald OutputEnum = iota // journald
+	OutputOTLP     OutputEnum = iota // otlp
 )
 
 func (e *OutputEnum) SetValue(value string) error {
@@ -47,6 +50,12 @@ func OutputFromString(value string) OutputEnum {
 		return OutputStderr
 	case "file":
 		return OutputFile
+	case "syslog":
+		return OutputSyslog
+	case "journald":
+		return OutputJournald
+	case "otlp":
+		return OutputOTLP
 	default:
 		return OutputUnknown
 	}
@@ -12,11 +12,14 @@ func _() {
 	_ = x[OutputStdout-1]
 	_ = x[OutputStderr-2]
 	_ = x[OutputFile-3]
+	_ = x[OutputSyslog-4]
+	_ = x[OutputJournald-5]
+	_ = x[OutputOTLP-6]
 }
 
-const _OutputEnum_name = "unknownstdoutstderrfile"
+const _OutputEnum_name = "unknownstdoutstderrfilesyslogjournaldotlp"
 
-var _OutputEnum_index = [...]uint8{0, 7, 13, 19, 23}
+var _OutputEnum_index = [...]uint8{0, 7, 13, 19, 23, 29, 37, 41}
 
 func (i OutputEnum) String() string {
 	idx := int(i) - 0
@@ -0,0 +1,6 @@
+//go:build race
+
+package libzap_test
+
+// raceEnabled skips the allocation tests, the race detector allocates on its own.
+const raceEnabled = true
@@ -0,0 +1,222 @@
+package libzap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// rateLimitMaxKeys bounds the entries tracked per interval, entries past it are not limited.
+const rateLimitMaxKeys = 4096
+
+type rateLimitKey struct {
+	name    string
+	level   zapcore.Level
+	message string
+}
+
+// rateLimiter is shared by a core and its children created by With.
+type rateLimiter struct {
+	mu       sync.Mutex
+	root     zapcore.Core
+	burst    int
+	interval time.Duration
+	start    time.Time
+	counts   map[rateLimitKey]int
+	timer    *time.Timer
+	stopped  bool
+}
+
+type rateLimitCore struct {
+	zapcore.Core
+
+	limiter *rateLimiter
+}
+
+// NewRateLimitCore logs at most burst entries with the same logger name, level and message per interval.
+// Once the interval ends, an "N messages suppressed" entry is logged for every limited message,
+// by a timer when no entry or Sync comes first. The core implements io.Closer to stop that timer.
+func NewRateLimitCore(core zapcore.Core, burst int, interval time.Duration) zapcore.Core { //nolint:ireturn
+	return newRateLimitCore(core, burst, interval)
+}
+
+func newRateLimitCore(core zapcore.Core, burst int, interval time.Duration) *rateLimitCore {
+	return &rateLimitCore{
+		Core: core,
+		limiter: &rateLimiter{
+			mu:       sync.Mutex{},
+			root:     core,
+			burst:    burst,
+			interval: interval,
+			start:    time.Time{},
+			counts:   map[rateLimitKey]int{},
+			timer:    nil,
+			stopped:  false,
+		},
+	}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) || !c.limiter.allow(entry) {
+		return checked
+	}
+
+	return c.Core.Check(entry, checked)
+}
+
+// Sync logs the summaries of the ended interval before syncing.
+func (c *rateLimitCore) Sync() error {
+	c.limiter.flush(time.Now())
+
+	return c.Core.Sync() //nolint:wrapcheck
+}
+
+// Close stops the summary timer before the wrapped core is closed, later summaries are left to Sync.
+func (c *rateLimitCore) Close() error {
+	c.limiter.stop()
+
+	return nil
+}
+
+func (l *rateLimiter) allow(entry zapcore.Entry) bool {
+	l.flush(entry.Time)
+
+	key := rateLimitKey{name: entry.LoggerName, level: entry.Level, message: entry.Message}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count, ok := l.counts[key]
+	if !ok && len(l.counts) >= rateLimitMaxKeys {
+		return true
+	}
+
+	l.counts[key] = count + 1
+
+	if count < l.burst {
+		return true
+	}
+
+	// report the suppressed entries once the interval ends even if nothing else is logged
+	if l.timer == nil && !l.stopped {
+		delay := min(max(l.start.Add(l.interval).Sub(entry.Time), 0), l.interval)
+		l.timer = time.AfterFunc(delay, l.flushPending)
+	}
+
+	return false
+}
+
+func (l *rateLimiter) flushPending() {
+	now := time.Now()
+
+	l.mu.Lock()
+
+	if l.stopped {
+		l.mu.Unlock()
+		return
+	}
+
+	if remaining := l.start.Add(l.interval).Sub(now); remaining > 0 {
+		l.timer.Reset(remaining)
+		l.mu.Unlock()
+
+		return
+	}
+
+	l.timer = nil
+
+	l.mu.Unlock()
+
+	l.flush(now)
+}
+
+func (l *rateLimiter) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopped = true
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
+
+// flush starts a new interval once the current one ended and logs its suppressed entries.
+func (l *rateLimiter) flush(now time.Time) {
+	l.mu.Lock()
+
+	if now.Sub(l.start) < l.interval {
+		l.mu.Unlock()
+		return
+	}
+
+	counts := l.counts
+	l.counts = make(map[rateLimitKey]int, len(counts))
+	l.start = now
+
+	l.mu.Unlock()
+
+	for key, count := range counts {
+		if count <= l.burst {
+			continue
+		}
+
+		// checked like any entry, a tee only writes the summary to the cores enabled for its level
+		checked := l.root.Check(zapcore.Entry{ //nolint:exhaustruct
+			Level:      key.level,
+			Time:       now,
+			LoggerName: key.name,
+			Message:    fmt.Sprintf("%d messages suppressed", count-l.burst),
+		}, nil)
+
+		checked.Write(zap.String("suppressed_msg", key.message))
+	}
+}
+
+// setSampling returns the sampler and rate limit options, the rate limit core is added to opened.
+func setSampling(presetCfg *PresetConfig, zcfg *zap.Config, opened *closers) []zap.Option {
+	// zap.Config samples with a fixed tick and wraps the core before the options, the sampler
+	// is added as an option instead
+	preset := zcfg.Sampling
+	zcfg.Sampling = nil
+
+	options := make([]zap.Option, 0, 2) //nolint:mnd
+	sampling := presetCfg.Sampling
+
+	switch {
+	case sampling.Disabled:
+	case sampling.Initial > 0 || sampling.Thereafter > 0 || sampling.Tick > 0:
+		if sampling.Tick <= 0 {
+			sampling.Tick = time.Second
+		}
+
+		options = append(options, samplerOption(sampling.Tick, sampling.Initial, sampling.Thereafter))
+	case preset != nil:
+		options = append(options, samplerOption(time.Second, preset.Initial, preset.Thereafter))
+	}
+
+	if rateLimit := presetCfg.RateLimit; rateLimit.Burst > 0 {
+		options = append(options, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			rateLimitCore := newRateLimitCore(core, rateLimit.Burst, rateLimit.Interval)
+			opened.add(rateLimitCore.Close)
+
+			return rateLimitCore
+		}))
+	}
+
+	return options
+}
+
+func samplerOption(tick time.Duration, initial, thereafter int) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, tick, initial, thereafter)
+	})
+}
@@ -0,0 +1,176 @@
+package libzap_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) NewTicker(duration time.Duration) *time.Ticker {
+	return time.NewTicker(duration)
+}
+
+func TestRateLimitCore(t *testing.T) {
+	t.Parallel()
+
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(libzap.NewRateLimitCore(core, 2, time.Second), zap.WithClock(clock)).With(zap.Int("id", 1))
+
+	for range 5 {
+		logger.Info("flood")
+	}
+
+	logger.Named("other").Info("flood")
+	logger.Debug("disabled")
+
+	clock.now = clock.now.Add(time.Second)
+	logger.Info("flood")
+
+	if got := messages(logs); got != "flood,flood,flood,3 messages suppressed,flood" {
+		t.Fatalf("messages: %s", got)
+	}
+
+	summary := logs.All()[3]
+	if summary.ContextMap()["suppressed_msg"] != "flood" || summary.LoggerName != "" {
+		t.Fatalf("summary: %+v", summary)
+	}
+
+	for range 3 {
+		logger.Info("flood")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	checkError(t, logger.Sync())
+
+	if last := logs.All()[logs.Len()-1]; last.Message != "2 messages suppressed" {
+		t.Fatalf("sync summary: %s", last.Message)
+	}
+}
+
+func TestRateLimitCoreTimerSummary(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(libzap.NewRateLimitCore(core, 1, 50*time.Millisecond))
+
+	for range 3 {
+		logger.Info("flood")
+	}
+
+	// nothing else is logged, the summary comes from the interval timer
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.FilterMessage("2 messages suppressed").Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := messages(logs); got != "flood,2 messages suppressed" {
+		t.Fatalf("messages: %s", got)
+	}
+}
+
+func TestRateLimitCoreTeeLevels(t *testing.T) {
+	t.Parallel()
+
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	infoCore, infoLogs := observer.New(zapcore.InfoLevel)
+	errorCore, errorLogs := observer.New(zapcore.ErrorLevel)
+	logger := zap.New(libzap.NewRateLimitCore(zapcore.NewTee(infoCore, errorCore), 1, time.Second), zap.WithClock(clock))
+
+	for range 3 {
+		logger.Warn("flood")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	checkError(t, logger.Sync())
+
+	if got := messages(infoLogs); got != "flood,2 messages suppressed" {
+		t.Fatalf("info messages: %s", got)
+	}
+
+	if errorLogs.Len() != 0 {
+		t.Fatalf("error messages: %s", messages(errorLogs))
+	}
+}
+
+func TestRateLimitCoreClose(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	rateLimitCore := libzap.NewRateLimitCore(core, 1, 20*time.Millisecond)
+	logger := zap.New(rateLimitCore)
+
+	for range 3 {
+		logger.Info("flood")
+	}
+
+	closer, ok := rateLimitCore.(io.Closer)
+	if !ok {
+		t.Fatal("rate limit core is not a closer")
+	}
+
+	checkError(t, closer.Close())
+
+	// the stopped timer must not write to the closed cores
+	time.Sleep(100 * time.Millisecond)
+
+	if got := messages(logs); got != "flood" {
+		t.Fatalf("messages: %s", got)
+	}
+}
+
+func TestPresetSampling(t *testing.T) {
+	t.Parallel()
+
+	for name, test := range map[string]struct {
+		sampling libzap.SamplingConfig
+		lines    int
+	}{
+		"preset":   {libzap.SamplingConfig{Disabled: false, Initial: 0, Thereafter: 0, Tick: 0}, 100},
+		"disabled": {libzap.SamplingConfig{Disabled: true, Initial: 0, Thereafter: 0, Tick: 0}, 150},
+		"custom":   {libzap.SamplingConfig{Disabled: false, Initial: 10, Thereafter: 20, Tick: time.Minute}, 17},
+	} {
+		dir := t.TempDir()
+		cfg := &libzap.Config{
+			Preset:      libzap.PresetProduction,
+			Development: libzap.PresetConfig{},
+			Production: libzap.PresetConfig{
+				Encoding:       libzap.EncodingJSON,
+				JSONMessageKey: "msg",
+				Outputs:        map[libzap.OutputEnum]bool{libzap.OutputFile: true},
+				OutputFile:     libzap.OutputFileConfig{Dir: dir, TimeLayout: "2006-01-02"}, //nolint:exhaustruct
+				Sampling:       test.sampling,
+			},
+		}
+
+		logger, err := libzap.New("service", cfg, nil)
+		checkError(t, err)
+
+		for range 150 {
+			logger.Info("sampled")
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "service-"+time.Now().Format("2006-01-02")+".log"))
+		checkError(t, err)
+
+		if lines := strings.Count(string(data), "\n"); lines != test.lines {
+			t.Fatalf("%s lines: %d != %d", name, lines, test.lines)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package libzap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redactor masks the fields with sensitive keys and the values matching sensitive patterns.
+// Only the message and the string fields are matched against the patterns, nested objects are not inspected.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func NewRedactor(cfg *RedactConfig) (*Redactor, error) {
+	redactor := &Redactor{
+		keys:     make([]string, 0, len(cfg.Keys)),
+		patterns: make([]*regexp.Regexp, 0, len(cfg.Patterns)),
+		mask:     cfg.Mask,
+	}
+
+	for _, key := range cfg.Keys {
+		if key = strings.TrimSpace(key); key != "" {
+			redactor.keys = append(redactor.keys, key)
+		}
+	}
+
+	for _, pattern := range cfg.Patterns {
+		if pattern == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("compile redact pattern: %w", err),
+				zap.String("pattern", pattern),
+			)
+		}
+
+		redactor.patterns = append(redactor.patterns, re)
+	}
+
+	return redactor, nil
+}
+
+// WrapCore masks the entries before core encodes them, use it with zap.WrapCore on cores that write
+// the entries they check, a tee is wrapped core by core to keep their levels.
+func (r *Redactor) WrapCore(core zapcore.Core) zapcore.Core { //nolint:ireturn
+	if len(r.keys) == 0 && len(r.patterns) == 0 {
+		return core
+	}
+
+	return &redactCore{Core: core, redactor: r}
+}
+
+// Fields returns fields with the sensitive ones masked, fields itself when none matches.
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	var result []zapcore.Field
+
+	for ind := range fields {
+		field, ok := r.field(&fields[ind])
+		if !ok {
+			continue
+		}
+
+		if result == nil {
+			result = make([]zapcore.Field, len(fields))
+			copy(result, fields)
+		}
+
+		result[ind] = field
+	}
+
+	if result == nil {
+		return fields
+	}
+
+	return result
+}
+
+// Value returns value with the parts matching the patterns masked.
+func (r *Redactor) Value(value string) string {
+	for _, re := range r.patterns {
+		if re.MatchString(value) {
+			value = re.ReplaceAllLiteralString(value, r.mask)
+		}
+	}
+
+	return value
+}
+
+func (r *Redactor) field(field *zapcore.Field) (zapcore.Field, bool) {
+	for _, key := range r.keys {
+		if strings.EqualFold(field.Key, key) {
+			return zap.String(field.Key, r.mask), true
+		}
+	}
+
+	var value string
+
+	switch field.Type { //nolint:exhaustive
+	case zapcore.StringType:
+		value = field.String
+	case zapcore.ByteStringType:
+		data, _ := field.Interface.([]byte)
+		if !r.match(data) {
+			return *field, false
+		}
+
+		value = string(data)
+	default:
+		return *field, false
+	}
+
+	if redacted := r.Value(value); redacted != value {
+		return zap.String(field.Key, redacted), true
+	}
+
+	return *field, false
+}
+
+func (r *Redactor) match(data []byte) bool {
+	for _, re := range r.patterns {
+		if re.Match(data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type redactCore struct {
+	zapcore.Core
+
+	redactor *Redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.Value(entry.Message)
+
+	return c.Core.Write(entry, c.redactor.Fields(fields)) //nolint:wrapcheck
+}
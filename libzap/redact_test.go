@@ -0,0 +1,90 @@
+package libzap_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestRedactor(t *testing.T) *libzap.Redactor {
+	t.Helper()
+
+	redactor, err := libzap.NewRedactor(&libzap.RedactConfig{
+		Keys:     []string{"password", "Token"},
+		Patterns: []string{`(?i)bearer\s+\S+`, `\b\d{4}(?:[ -]?\d{4}){3}\b`},
+		Mask:     "***",
+	})
+	checkError(t, err)
+
+	return redactor
+}
+
+func TestRedactCore(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(newTestRedactor(t).WrapCore(core)).With(zap.String("TOKEN", "secret"))
+
+	logger.Info(
+		"card 4111 1111 1111 1111 charged",
+		zap.String("password", "hunter2"),
+		zap.Int("token", 42),
+		zap.ByteString("header", []byte("Authorization: Bearer abc.def")),
+		zap.String("user", "alice"),
+	)
+
+	entry := logs.All()[0]
+	fields := entry.ContextMap()
+
+	if entry.Message != "card *** charged" {
+		t.Fatalf("message: %s", entry.Message)
+	}
+
+	for key, value := range map[string]any{
+		"TOKEN":    "***",
+		"password": "***",
+		"token":    "***",
+		"header":   "Authorization: ***",
+		"user":     "alice",
+	} {
+		if fields[key] != value {
+			t.Fatalf("%s: %v != %v", key, fields[key], value)
+		}
+	}
+
+	if _, err := libzap.NewRedactor(&libzap.RedactConfig{Keys: nil, Patterns: []string{"("}, Mask: ""}); err == nil {
+		t.Fatal("invalid pattern accepted")
+	}
+}
+
+func TestRedactCoreAllocs(t *testing.T) { //nolint:paralleltest // AllocsPerRun refuses parallel tests
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+
+	base := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard),
+		zapcore.InfoLevel,
+	)
+	redacted := newTestRedactor(t).WrapCore(base)
+
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Message: "request served"} //nolint:exhaustruct
+	fields := []zapcore.Field{zap.String("path", "/api"), zap.Int("status", 200)}
+
+	baseAllocs := testing.AllocsPerRun(100, func() {
+		_ = base.Write(entry, fields)
+	})
+
+	redactedAllocs := testing.AllocsPerRun(100, func() {
+		_ = redacted.Write(entry, fields)
+	})
+
+	if redactedAllocs != baseAllocs {
+		t.Fatalf("allocs: %v != %v", redactedAllocs, baseAllocs)
+	}
+}
@@ -0,0 +1,462 @@
+package libzap
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	rotateSinkScheme = "libzap-rotate"
+	rotateFilePerm   = 0o644
+	rotateDirPerm    = 0o755
+	logExt           = ".log"
+	gzExt            = ".gz"
+	megabyte         = 1 << 20
+)
+
+var (
+	ErrRotatingFileClosed = errors.New("rotating file closed")
+
+	registerRotateSinkOnce sync.Once //nolint:gochecknoglobals
+	errRegisterRotateSink  error     //nolint:gochecknoglobals
+)
+
+// RotatingFile is a zap sink writing to dir/appID-time.log. The file is rotated when the time formatted
+// with TimeLayout changes and, with MaxSize, when it would grow past MaxSize megabytes, size rotated files
+// are renamed to appID-time.N.log. Rotated files are compressed and removed by MaxBackups and MaxAge
+// in the background, a failure there is returned by the next Sync or Close.
+type RotatingFile struct {
+	mu       sync.Mutex
+	dir      string
+	appID    string
+	cfg      OutputFileConfig
+	file     *os.File
+	stamp    string
+	size     int64
+	closed   bool
+	millErr  error
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+func NewRotatingFile(dir, appID string, cfg *OutputFileConfig) (*RotatingFile, error) {
+	if err := os.MkdirAll(dir, rotateDirPerm); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+
+	rotatingFile := &RotatingFile{
+		mu:       sync.Mutex{},
+		dir:      dir,
+		appID:    appID,
+		cfg:      *cfg,
+		file:     nil,
+		stamp:    "",
+		size:     0,
+		closed:   false,
+		millErr:  nil,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+
+	if err := rotatingFile.open(time.Now().Format(cfg.TimeLayout)); err != nil {
+		return nil, err
+	}
+
+	go rotatingFile.mill()
+
+	rotatingFile.millCh <- struct{}{}
+
+	return rotatingFile, nil
+}
+
+func (r *RotatingFile) Write(buff []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, ErrRotatingFileClosed
+	}
+
+	if stamp := time.Now().Format(r.cfg.TimeLayout); stamp != r.stamp {
+		if err := r.rotate(stamp, false); err != nil {
+			return 0, err
+		}
+	}
+
+	if maxSize := int64(r.cfg.MaxSize) * megabyte; maxSize > 0 && r.size > 0 && r.size+int64(len(buff)) > maxSize {
+		if err := r.rotate(r.stamp, true); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(buff)
+	r.size += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+// Rotate closes the current file, renames it to a backup name and opens a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrRotatingFileClosed
+	}
+
+	return r.rotate(time.Now().Format(r.cfg.TimeLayout), true)
+}
+
+// Sync flushes the file and returns the background compression and cleanup error since the last call.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	millErr := r.millErr
+	r.millErr = nil
+
+	return errors.Join(r.file.Sync(), millErr)
+}
+
+// Close closes the file and waits for the background compression and cleanup, its pending error is returned.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+
+	r.closed = true
+	err := r.file.Close()
+	close(r.millCh)
+
+	r.mu.Unlock()
+
+	<-r.millDone
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(err, r.millErr)
+}
+
+// Name returns the path of the file being written.
+func (r *RotatingFile) Name() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Name()
+}
+
+func (r *RotatingFile) activeName(stamp string) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%s-%s%s", r.appID, stamp, logExt))
+}
+
+func (r *RotatingFile) open(stamp string) error {
+	file, err := os.OpenFile(r.activeName(stamp), os.O_CREATE|os.O_WRONLY|os.O_APPEND, rotateFilePerm)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	r.file, r.stamp, r.size = file, stamp, stat.Size()
+
+	return nil
+}
+
+func (r *RotatingFile) rotate(stamp string, rename bool) error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+
+	if rename {
+		if err := os.Rename(r.file.Name(), r.backupName()); err != nil {
+			return fmt.Errorf("rename log file: %w", err)
+		}
+	}
+
+	if err := r.open(stamp); err != nil {
+		return err
+	}
+
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (r *RotatingFile) backupName() string {
+	for seq := 1; ; seq++ {
+		name := filepath.Join(r.dir, fmt.Sprintf("%s-%s.%d%s", r.appID, r.stamp, seq, logExt))
+
+		if !fileExists(name) && !fileExists(name+gzExt) {
+			return name
+		}
+	}
+}
+
+func (r *RotatingFile) mill() {
+	defer close(r.millDone)
+
+	for range r.millCh {
+		// the first error is kept until Sync or Close returns it
+		if err := r.millOnce(); err != nil {
+			r.mu.Lock()
+			if r.millErr == nil {
+				r.millErr = fmt.Errorf("rotate log files: %w", err)
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+type logBackup struct {
+	path    string
+	modTime time.Time
+}
+
+// millOnce compresses the backups and removes the ones exceeding MaxBackups and MaxAge.
+func (r *RotatingFile) millOnce() error {
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	if r.cfg.Compress {
+		for ind, backup := range backups {
+			if strings.HasSuffix(backup.path, gzExt) {
+				continue
+			}
+
+			if err := compressFile(backup.path); err != nil {
+				return fmt.Errorf("compress %s: %w", backup.path, err)
+			}
+
+			backups[ind].path += gzExt
+		}
+	}
+
+	slices.SortFunc(backups, func(a, b logBackup) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	for ind, backup := range backups {
+		tooMany := r.cfg.MaxBackups > 0 && ind >= r.cfg.MaxBackups
+		tooOld := r.cfg.MaxAge > 0 && time.Since(backup.modTime) > r.cfg.MaxAge
+
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := os.Remove(backup.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", backup.path, err)
+		}
+	}
+
+	return nil
+}
+
+// backups lists the rotated files of appID, the file being written is excluded.
+func (r *RotatingFile) backups() ([]logBackup, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	r.mu.Lock()
+	active := filepath.Base(r.activeName(r.stamp))
+	r.mu.Unlock()
+
+	var backups []logBackup
+
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == active || !r.isBackup(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, logBackup{
+			path:    filepath.Join(r.dir, entry.Name()),
+			modTime: info.ModTime(),
+		})
+	}
+
+	return backups, nil
+}
+
+// isBackup matches appID-time.log and appID-time.N.log with an optional gz extension.
+func (r *RotatingFile) isBackup(name string) bool {
+	stamp, ok := strings.CutPrefix(name, r.appID+"-")
+	if !ok {
+		return false
+	}
+
+	stamp = strings.TrimSuffix(stamp, gzExt)
+
+	if stamp, ok = strings.CutSuffix(stamp, logExt); !ok {
+		return false
+	}
+
+	if _, err := time.Parse(r.cfg.TimeLayout, stamp); err == nil {
+		return true
+	}
+
+	ind := strings.LastIndexByte(stamp, '.')
+	if ind < 0 {
+		return false
+	}
+
+	if _, err := strconv.Atoi(stamp[ind+1:]); err != nil {
+		return false
+	}
+
+	_, err := time.Parse(r.cfg.TimeLayout, stamp[:ind])
+
+	return err == nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	defer src.Close()
+
+	stat, err := src.Stat()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	tmpPath := path + gzExt + ".tmp"
+
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, rotateFilePerm)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	gzWriter := gzip.NewWriter(dst)
+
+	_, err = io.Copy(gzWriter, src)
+	if err == nil {
+		err = gzWriter.Close()
+	}
+
+	if err == nil {
+		err = dst.Sync()
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err //nolint:wrapcheck
+	}
+
+	// keep the modification time, it orders the backups
+	if err := os.Chtimes(tmpPath, stat.ModTime(), stat.ModTime()); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err := os.Rename(tmpPath, path+gzExt); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return os.Remove(path) //nolint:wrapcheck
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// rotateSinkURL encodes the file output options in a sink url, zap opens it with newRotateSink.
+func rotateSinkURL(dir, appID string, cfg *OutputFileConfig) string {
+	query := url.Values{}
+	query.Set("timeLayout", cfg.TimeLayout)
+	query.Set("maxSize", strconv.Itoa(cfg.MaxSize))
+	query.Set("maxBackups", strconv.Itoa(cfg.MaxBackups))
+	query.Set("maxAge", cfg.MaxAge.String())
+	query.Set("compress", strconv.FormatBool(cfg.Compress))
+
+	sinkURL := url.URL{
+		Scheme:   rotateSinkScheme,
+		Path:     filepath.ToSlash(filepath.Join(dir, appID)),
+		RawQuery: query.Encode(),
+	}
+
+	return sinkURL.String()
+}
+
+func registerRotateSink() error {
+	registerRotateSinkOnce.Do(func() {
+		errRegisterRotateSink = zap.RegisterSink(rotateSinkScheme, newRotateSink)
+	})
+
+	return errRegisterRotateSink
+}
+
+func newRotateSink(sinkURL *url.URL) (zap.Sink, error) { //nolint:ireturn
+	query := sinkURL.Query()
+	dir, appID := filepath.Split(filepath.FromSlash(sinkURL.Path))
+
+	cfg := &OutputFileConfig{
+		Dir:        dir,
+		TimeLayout: query.Get("timeLayout"),
+		MaxSize:    0,
+		MaxBackups: 0,
+		MaxAge:     0,
+		Compress:   false,
+	}
+
+	var err error
+
+	if cfg.MaxSize, err = strconv.Atoi(query.Get("maxSize")); err != nil {
+		return nil, fmt.Errorf("parse max size: %w", err)
+	}
+
+	if cfg.MaxBackups, err = strconv.Atoi(query.Get("maxBackups")); err != nil {
+		return nil, fmt.Errorf("parse max backups: %w", err)
+	}
+
+	if cfg.MaxAge, err = time.ParseDuration(query.Get("maxAge")); err != nil {
+		return nil, fmt.Errorf("parse max age: %w", err)
+	}
+
+	if cfg.Compress, err = strconv.ParseBool(query.Get("compress")); err != nil {
+		return nil, fmt.Errorf("parse compress: %w", err)
+	}
+
+	return NewRotatingFile(dir, appID, cfg)
+}
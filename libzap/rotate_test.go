@@ -0,0 +1,179 @@
+package libzap_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	rotatingFile, err := libzap.NewRotatingFile(dir, "app", &libzap.OutputFileConfig{
+		Dir:        dir,
+		TimeLayout: "2006-01-02",
+		MaxSize:    1,
+		MaxBackups: 2,
+		MaxAge:     0,
+		Compress:   true,
+	})
+	checkError(t, err)
+
+	chunk := bytes.Repeat([]byte("x"), 600<<10)
+
+	for range 6 {
+		_, err := rotatingFile.Write(chunk)
+		checkError(t, err)
+	}
+
+	active := rotatingFile.Name()
+	checkError(t, rotatingFile.Close())
+
+	entries, err := os.ReadDir(dir)
+	checkError(t, err)
+
+	var backups []string
+
+	for _, entry := range entries {
+		if filepath.Join(dir, entry.Name()) == active {
+			continue
+		}
+
+		backups = append(backups, entry.Name())
+
+		if !strings.HasSuffix(entry.Name(), ".log.gz") {
+			t.Fatalf("uncompressed backup: %s", entry.Name())
+		}
+	}
+
+	if len(backups) != 2 || filepath.Base(active) != "app-"+time.Now().Format("2006-01-02")+".log" {
+		t.Fatalf("active %s backups %v", active, backups)
+	}
+}
+
+func TestRotatingFileMillError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	stamp := time.Now().Format("2006-01-02")
+
+	// a directory in place of the temporary gz file fails the compression of the first backup
+	checkError(t, os.Mkdir(filepath.Join(dir, "app-"+stamp+".1.log.gz.tmp"), 0o755))
+
+	rotatingFile, err := libzap.NewRotatingFile(dir, "app", &libzap.OutputFileConfig{
+		Dir:        dir,
+		TimeLayout: "2006-01-02",
+		MaxSize:    0,
+		MaxBackups: 0,
+		MaxAge:     0,
+		Compress:   true,
+	})
+	checkError(t, err)
+
+	_, err = rotatingFile.Write([]byte("backup"))
+	checkError(t, err)
+	checkError(t, rotatingFile.Rotate())
+
+	if err := rotatingFile.Close(); err == nil || !strings.Contains(err.Error(), "rotate log files") {
+		t.Fatalf("close error: %v", err)
+	}
+}
+
+func TestFileOutput(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Encoding:       libzap.EncodingJSON,
+			JSONMessageKey: "msg",
+			Outputs:        map[libzap.OutputEnum]bool{libzap.OutputFile: true},
+			OutputFile: libzap.OutputFileConfig{
+				Dir:        dir,
+				TimeLayout: "2006-01-02",
+				MaxSize:    0,
+				MaxBackups: 0,
+				MaxAge:     0,
+				Compress:   false,
+			},
+		},
+	}
+
+	logger, err := libzap.New("service", cfg, nil)
+	checkError(t, err)
+
+	// the rotating file is not buffered, no sync needed
+	logger.Info("rotating file output")
+
+	data, err := os.ReadFile(filepath.Join(dir, "service-"+time.Now().Format("2006-01-02")+".log"))
+	checkError(t, err)
+
+	if !strings.Contains(string(data), "rotating file output") {
+		t.Fatalf("log file: %q", data)
+	}
+}
+
+//nolint:paralleltest // counts the goroutines of the process
+func TestFileOutputClose(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Encoding:       libzap.EncodingJSON,
+			JSONMessageKey: "msg",
+			Outputs:        map[libzap.OutputEnum]bool{libzap.OutputFile: true},
+			OutputFile: libzap.OutputFileConfig{
+				Dir:        dir,
+				TimeLayout: "2006-01-02",
+				MaxSize:    0,
+				MaxBackups: 0,
+				MaxAge:     0,
+				Compress:   false,
+			},
+		},
+	}
+
+	before := runtime.NumGoroutine()
+
+	logger, _, closeLogger, err := libzap.NewWithClose("service", cfg, nil)
+	checkError(t, err)
+
+	logger.Info("before close")
+	checkError(t, closeLogger())
+	logger.Info("after close")
+
+	data, err := os.ReadFile(filepath.Join(dir, "service-"+time.Now().Format("2006-01-02")+".log"))
+	checkError(t, err)
+
+	if !strings.Contains(string(data), "before close") || strings.Contains(string(data), "after close") {
+		t.Fatalf("log file: %q", data)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d > %d", runtime.NumGoroutine(), before)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,218 @@
+package libzap
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sinkOmitKey omits a sink entry, an empty key inherits the preset one.
+const sinkOmitKey = "-"
+
+// setSinks returns the option replacing the core built from the outputs by a tee of the sink cores,
+// the files opened for the sinks are added to opened. File sinks are skipped when rcfg disables them.
+func setSinks(
+	appID string,
+	presetCfg *PresetConfig,
+	zcfg *zap.Config,
+	rcfg *RuntimeConfig,
+	redactor *Redactor,
+	opened *closers,
+) (zap.Option, error) {
+	if len(presetCfg.Sinks) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	cores := make([]zapcore.Core, 0, len(presetCfg.Sinks))
+
+	for ind := range presetCfg.Sinks {
+		if presetCfg.Sinks[ind].Output == OutputFile && rcfg != nil && !rcfg.OutputFileEnabled {
+			continue
+		}
+
+		core, err := newSinkCore(appID, presetCfg, zcfg, &presetCfg.Sinks[ind], opened)
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("new sink core: %w", err),
+				zap.Int("sink", ind),
+			)
+		}
+
+		cores = append(cores, redactor.WrapCore(core))
+	}
+
+	tee := zapcore.NewTee(cores...)
+
+	return zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return tee
+	}), nil
+}
+
+func newSinkCore( //nolint:ireturn
+	appID string,
+	presetCfg *PresetConfig,
+	zcfg *zap.Config,
+	sink *SinkConfig,
+	opened *closers,
+) (zapcore.Core, error) {
+	// the logger levels filter the entries before the sinks, without a level the sink takes all of them
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	if sink.Level != "" {
+		var err error
+
+		if level, err = zap.ParseAtomicLevel(sink.Level); err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("parse atomic level: %w", err),
+				zap.String("level", sink.Level),
+			)
+		}
+	}
+
+	encoding := sink.Encoding
+	if encoding == EncodingUnknown {
+		encoding = presetCfg.Encoding
+	}
+
+	encoder, err := newEncoder(encoding, sinkEncoderConfig(presetCfg, zcfg.EncoderConfig, encoding, sink))
+	if err != nil {
+		return nil, err
+	}
+
+	if sink.Output == OutputSyslog || sink.Output == OutputJournald || sink.Output == OutputOTLP {
+		return newOutputCore(appID, presetCfg, sink.Output, encoder, level, opened)
+	}
+
+	path, err := sinkPath(appID, presetCfg, sink)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, closeWriter, err := zap.Open(path)
+	if err != nil {
+		return nil, zerr.Wrap(
+			fmt.Errorf("open sink: %w", err),
+			zap.String("path", path),
+		)
+	}
+
+	opened.add(func() error {
+		closeWriter()
+		return nil
+	})
+
+	return zapcore.NewCore(encoder, writer, level), nil
+}
+
+func newEncoder(encoding EncodingEnum, encoderCfg zapcore.EncoderConfig) (zapcore.Encoder, error) { //nolint:ireturn
+	switch encoding {
+	case EncodingJSON:
+		return zapcore.NewJSONEncoder(encoderCfg), nil
+	case EncodingConsole:
+		return zapcore.NewConsoleEncoder(encoderCfg), nil
+	case EncodingUnknown:
+		fallthrough
+	default:
+		return nil, ErrUnknownEncoding
+	}
+}
+
+// newOutputCore creates the core of the outputs that are not zap sinks, syslog, journald and otlp,
+// its connection or exporter is added to opened.
+func newOutputCore( //nolint:ireturn
+	appID string,
+	presetCfg *PresetConfig,
+	output OutputEnum,
+	encoder zapcore.Encoder,
+	enabler zapcore.LevelEnabler,
+	opened *closers,
+) (zapcore.Core, error) {
+	var (
+		core zapcore.Core
+		err  error
+	)
+
+	switch output {
+	case OutputSyslog:
+		core, err = NewSyslogCore(encoder, enabler, appID, &presetCfg.Syslog)
+	case OutputJournald:
+		core, err = NewJournaldCore(enabler, appID, &presetCfg.Journald)
+	case OutputOTLP:
+		core = NewOTLPCore(enabler, appID, &presetCfg.OTLP)
+	case OutputUnknown, OutputStdout, OutputStderr, OutputFile:
+		fallthrough
+	default:
+		return nil, ErrUnknownOutput
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := core.(io.Closer); ok {
+		opened.add(closer.Close)
+	}
+
+	return core, nil
+}
+
+func sinkPath(appID string, presetCfg *PresetConfig, sink *SinkConfig) (string, error) {
+	switch sink.Output {
+	case OutputStdout, OutputStderr:
+		return sink.Output.String(), nil
+	case OutputFile:
+		fileCfg := &sink.OutputFile
+		if fileCfg.Dir == "" {
+			fileCfg = &presetCfg.OutputFile
+		}
+
+		dir, err := outputFileDir(fileCfg)
+		if err != nil {
+			return "", err
+		}
+
+		if err := registerRotateSink(); err != nil {
+			return "", fmt.Errorf("register rotate sink: %w", err)
+		}
+
+		return rotateSinkURL(dir, appID, fileCfg), nil
+	case OutputUnknown, OutputSyslog, OutputJournald, OutputOTLP:
+		fallthrough
+	default:
+		return "", ErrUnknownOutput
+	}
+}
+
+func sinkEncoderConfig(
+	presetCfg *PresetConfig,
+	encoderCfg zapcore.EncoderConfig,
+	encoding EncodingEnum,
+	sink *SinkConfig,
+) zapcore.EncoderConfig {
+	if encoding == EncodingJSON {
+		setJSONKeys(presetCfg, &encoderCfg)
+	}
+
+	for key, value := range map[*string]string{
+		&encoderCfg.TimeKey:       sink.TimeKey,
+		&encoderCfg.LevelKey:      sink.LevelKey,
+		&encoderCfg.NameKey:       sink.NameKey,
+		&encoderCfg.CallerKey:     sink.CallerKey,
+		&encoderCfg.FunctionKey:   sink.FunctionKey,
+		&encoderCfg.MessageKey:    sink.MessageKey,
+		&encoderCfg.StacktraceKey: sink.StacktraceKey,
+	} {
+		switch value {
+		case "":
+		case sinkOmitKey:
+			*key = zapcore.OmitKey
+		default:
+			*key = value
+		}
+	}
+
+	return encoderCfg
+}
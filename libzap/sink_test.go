@@ -0,0 +1,119 @@
+package libzap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSinks(t *testing.T) {
+	t.Parallel()
+
+	jsonDir, consoleDir := t.TempDir(), t.TempDir()
+	fileCfg := func(dir string) libzap.OutputFileConfig {
+		return libzap.OutputFileConfig{
+			Dir:        dir,
+			TimeLayout: "2006-01-02",
+			MaxSize:    0,
+			MaxBackups: 0,
+			MaxAge:     0,
+			Compress:   false,
+		}
+	}
+
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Level:          "debug",
+			Encoding:       libzap.EncodingConsole,
+			JSONMessageKey: "msg",
+			JSONLevelKey:   "level",
+			Sinks: []libzap.SinkConfig{
+				{
+					Output:     libzap.OutputFile,
+					Encoding:   libzap.EncodingJSON,
+					MessageKey: "message",
+					LevelKey:   "-",
+					OutputFile: fileCfg(jsonDir),
+				},
+				{
+					Output:     libzap.OutputFile,
+					Level:      "warn",
+					OutputFile: fileCfg(consoleDir),
+				},
+			},
+		},
+	}
+
+	logger, err := libzap.New("service", cfg, nil)
+	checkError(t, err)
+
+	logger.Debug("debug entry")
+	logger.Warn("warn entry")
+
+	name := "service-" + time.Now().Format("2006-01-02") + ".log"
+
+	jsonData, err := os.ReadFile(filepath.Join(jsonDir, name))
+	checkError(t, err)
+
+	consoleData, err := os.ReadFile(filepath.Join(consoleDir, name))
+	checkError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(jsonData)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"message":"debug entry"`) || strings.Contains(lines[0], "level") {
+		t.Fatalf("json sink: %q", jsonData)
+	}
+
+	if strings.Contains(string(consoleData), "debug entry") || !strings.Contains(string(consoleData), "\twarn entry") {
+		t.Fatalf("console sink: %q", consoleData)
+	}
+}
+
+func TestSinksOutputFileDisabled(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Encoding: libzap.EncodingJSON,
+			Sinks: []libzap.SinkConfig{
+				{
+					Output: libzap.OutputFile,
+					OutputFile: libzap.OutputFileConfig{
+						Dir:        dir,
+						TimeLayout: "2006-01-02",
+						MaxSize:    0,
+						MaxBackups: 0,
+						MaxAge:     0,
+						Compress:   false,
+					},
+				},
+			},
+		},
+	}
+
+	logger, _, closeLogger, err := libzap.NewWithClose(
+		"service",
+		cfg,
+		&libzap.RuntimeConfig{Level: zapcore.InfoLevel, OutputFileEnabled: false},
+	)
+	checkError(t, err)
+
+	logger.Info("file disabled")
+	checkError(t, closeLogger())
+
+	entries, err := os.ReadDir(dir)
+	checkError(t, err)
+
+	if len(entries) != 0 {
+		t.Fatalf("file sink written: %v", entries)
+	}
+}
@@ -0,0 +1,322 @@
+package libzap
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap/zfield"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler writing to a zap core, groups become zap namespaces.
+type SlogHandler struct {
+	core   zapcore.Core
+	name   string
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing to the core of logger with its name,
+// the trace fields of the record context are added like FromContext does.
+func NewSlogHandler(logger *zap.Logger) *SlogHandler {
+	return &SlogHandler{
+		core:   logger.Core(),
+		name:   logger.Name(),
+		groups: nil,
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{ //nolint:exhaustruct
+		Level:      zapLevel(record.Level),
+		Time:       record.Time,
+		LoggerName: h.name,
+		Message:    record.Message,
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	checked := h.core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	attrs := make([]zapcore.Field, 0, record.NumAttrs())
+
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, attr)
+		return true
+	})
+
+	// the trace fields stay out of the groups, a group without attributes is omitted
+	fields := zfield.TraceFields(ctx)
+
+	if len(attrs) > 0 {
+		fields = append(append(fields, groupNamespaces(h.groups)...), attrs...)
+	}
+
+	checked.Write(fields...)
+
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler { //nolint:ireturn
+	fields := make([]zapcore.Field, 0, len(attrs))
+
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+
+	if len(fields) == 0 {
+		return h
+	}
+
+	return &SlogHandler{
+		core:   h.core.With(append(groupNamespaces(h.groups), fields...)),
+		name:   h.name,
+		groups: nil,
+	}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler { //nolint:ireturn
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{
+		core:   h.core,
+		name:   h.name,
+		groups: append(slices.Clip(h.groups), name),
+	}
+}
+
+func groupNamespaces(groups []string) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(groups))
+
+	for _, group := range groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+
+	return fields
+}
+
+func appendSlogAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+
+	if attr.Equal(slog.Attr{}) { //nolint:exhaustruct
+		return fields
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+
+		// a group with an empty key is inlined
+		if attr.Key == "" {
+			for _, item := range group {
+				fields = appendSlogAttr(fields, item)
+			}
+
+			return fields
+		}
+
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	case slog.KindAny, slog.KindLogValuer:
+		fallthrough
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	for _, field := range appendSlogAttr(nil, slog.GroupAttrs("", g...)) {
+		field.AddTo(encoder)
+	}
+
+	return nil
+}
+
+// zapLevel maps the slog levels to the closest zap level below or equal to them.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// slogLevel maps the zap levels to slog levels, the levels above error follow slog.LevelError.
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError + slog.Level(level-zapcore.ErrorLevel)
+	}
+}
+
+// slogCore is a zap core writing to a slog.Handler, zap namespaces become groups.
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewSlogCore returns a zap core writing to handler, the logger name and stack trace are added
+// as the logger and stacktrace attributes.
+func NewSlogCore(handler slog.Handler) zapcore.Core { //nolint:ireturn
+	return &slogCore{handler: handler}
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	handler := c.handler
+	attrs := make([]slog.Attr, 0, len(fields))
+
+	for _, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			if len(attrs) > 0 {
+				handler = handler.WithAttrs(attrs)
+				attrs = attrs[:0:0]
+			}
+
+			handler = handler.WithGroup(field.Key)
+
+			continue
+		}
+
+		attrs = append(attrs, slogAttr(field))
+	}
+
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
+	}
+
+	return &slogCore{handler: handler}
+}
+
+func (c *slogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *slogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, entry.Caller.PC)
+
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String("logger", entry.LoggerName))
+	}
+
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String("stacktrace", entry.Stack))
+	}
+
+	// the fields following a namespace are nested in a group, innermost first
+	var groups [][]slog.Attr
+
+	attrs := make([]slog.Attr, 0, len(fields))
+
+	for _, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			groups = append(groups, attrs)
+			attrs = []slog.Attr{{Key: field.Key, Value: slog.Value{}}}
+
+			continue
+		}
+
+		attrs = append(attrs, slogAttr(field))
+	}
+
+	for ind := len(groups) - 1; ind >= 0; ind-- {
+		attrs = append(groups[ind], slog.GroupAttrs(attrs[0].Key, attrs[1:]...))
+	}
+
+	record.AddAttrs(attrs...)
+
+	return c.handler.Handle(context.Background(), record) //nolint:wrapcheck
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+func slogAttr(field zapcore.Field) slog.Attr {
+	switch field.Type { //nolint:exhaustive
+	case zapcore.StringType:
+		return slog.String(field.Key, field.String)
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return slog.Int64(field.Key, field.Integer)
+	case zapcore.BoolType:
+		return slog.Bool(field.Key, field.Integer == 1)
+	case zapcore.DurationType:
+		return slog.Duration(field.Key, time.Duration(field.Integer))
+	case zapcore.ErrorType:
+		return slog.Any(field.Key, field.Interface)
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	field.AddTo(encoder)
+
+	if value, ok := encoder.Fields[field.Key]; ok && len(encoder.Fields) == 1 {
+		return slog.Any(field.Key, value)
+	}
+
+	// fields like zap.Error with a verbose form add several keys
+	return slog.Any(field.Key, encoder.Fields)
+}
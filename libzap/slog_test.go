@@ -0,0 +1,79 @@
+package libzap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := slog.New(libzap.NewSlogHandler(zap.New(core).Named("bridge")))
+
+	logger.Debug("disabled")
+	logger.With("app", "svc").WithGroup("req").Info("served", "status", 200, slog.Group("user", "id", 7))
+	logger.WithGroup("empty").Warn("no attrs")
+	logger.Error("failed", "err", errors.New("boom"))
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("entries: %d", len(entries))
+	}
+
+	served := entries[0]
+	if served.LoggerName != "bridge" || !served.Caller.Defined || filepath.Base(served.Caller.File) != "slog_test.go" {
+		t.Fatalf("served entry: %+v", served.Entry)
+	}
+
+	data, err := json.Marshal(served.ContextMap())
+	checkError(t, err)
+
+	if string(data) != `{"app":"svc","req":{"status":200,"user":{"id":7}}}` {
+		t.Fatalf("served fields: %s", data)
+	}
+
+	if len(entries[1].Context) != 0 || entries[1].Level != zapcore.WarnLevel {
+		t.Fatalf("empty group entry: %+v", entries[1])
+	}
+
+	if entries[2].Level != zapcore.ErrorLevel || entries[2].ContextMap()["err"] != "boom" {
+		t.Fatalf("error entry: %+v", entries[2])
+	}
+}
+
+func TestSlogCore(t *testing.T) {
+	t.Parallel()
+
+	var buff bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buff, &slog.HandlerOptions{AddSource: false, Level: slog.LevelInfo, ReplaceAttr: nil})
+	logger := zap.New(libzap.NewSlogCore(handler)).Named("zap").With(zap.String("app", "svc"), zap.Namespace("req"))
+
+	logger.Debug("disabled")
+	logger.Warn("served", zap.Int("status", 200), zap.Namespace("user"), zap.Uint("id", 7), zap.Error(errors.New("boom")))
+
+	var record map[string]any
+
+	checkError(t, json.Unmarshal(buff.Bytes(), &record))
+
+	delete(record, "time")
+
+	data, err := json.Marshal(record)
+	checkError(t, err)
+
+	want := `{"app":"svc","level":"WARN","msg":"served",` +
+		`"req":{"logger":"zap","status":200,"user":{"error":"boom","id":7}}}`
+	if string(data) != want {
+		t.Fatalf("record: %s", data)
+	}
+}
@@ -0,0 +1,254 @@
+package libzap
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grinderz/go-libs/liberrors"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	syslogVersion      = 1
+	syslogNilValue     = "-"
+	syslogTimeLayout   = "2006-01-02T15:04:05.000000Z07:00"
+	syslogHostnameSize = 255
+	syslogAppNameSize  = 48
+	syslogMsgIDSize    = 32
+	syslogFacilityBits = 3
+)
+
+var syslogFacilities = map[string]int{ //nolint:gochecknoglobals
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity maps the zap levels to the syslog severities, the journal PRIORITY field uses them too.
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7 //nolint:mnd
+	case zapcore.InfoLevel:
+		return 6 //nolint:mnd
+	case zapcore.WarnLevel:
+		return 4 //nolint:mnd
+	case zapcore.ErrorLevel:
+		return 3 //nolint:mnd
+	case zapcore.DPanicLevel:
+		return 2 //nolint:mnd
+	case zapcore.PanicLevel:
+		return 1
+	case zapcore.FatalLevel:
+		return 0
+	case zapcore.InvalidLevel:
+		fallthrough
+	default:
+		return 5 //nolint:mnd
+	}
+}
+
+// syslogWriter sends framed messages, it reconnects once when a write fails.
+type syslogWriter struct {
+	mu      sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+}
+
+func (w *syslogWriter) dial() error {
+	network := w.network
+	if network == "unix" {
+		network = "unixgram"
+	}
+
+	conn, err := net.Dial(network, w.address)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	w.conn = conn
+
+	return nil
+}
+
+func (w *syslogWriter) write(msg []byte) error {
+	// stream transports use octet counting framing (RFC 6587), datagrams carry one message each
+	if w.network == "tcp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if err := w.dial(); err != nil {
+		return err
+	}
+
+	_, err := w.conn.Write(msg)
+
+	return err //nolint:wrapcheck
+}
+
+func (w *syslogWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err //nolint:wrapcheck
+}
+
+type syslogCore struct {
+	zapcore.LevelEnabler
+
+	encoder  zapcore.Encoder
+	writer   *syslogWriter
+	facility int
+	hostname string
+	appName  string
+	procID   string
+}
+
+// NewSyslogCore writes RFC 5424 messages with the entries encoded by encoder as MSG.
+// The logger name is sent as MSGID.
+func NewSyslogCore( //nolint:ireturn
+	encoder zapcore.Encoder,
+	enabler zapcore.LevelEnabler,
+	appID string,
+	cfg *SyslogConfig,
+) (zapcore.Core, error) {
+	facility, ok := syslogFacilities[strings.ToLower(cfg.Facility)]
+	if !ok {
+		return nil, liberrors.NewInvalidStringEntityError("syslog_facility", cfg.Facility)
+	}
+
+	switch cfg.Network {
+	case "unix", "udp", "tcp":
+	default:
+		return nil, liberrors.NewInvalidStringEntityError("syslog_network", cfg.Network)
+	}
+
+	writer := &syslogWriter{
+		mu:      sync.Mutex{},
+		network: cfg.Network,
+		address: cfg.Address,
+		conn:    nil,
+	}
+
+	if err := writer.dial(); err != nil {
+		return nil, fmt.Errorf("dial syslog: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	appName := cfg.AppName
+	if appName == "" {
+		appName = appID
+	}
+
+	return &syslogCore{
+		LevelEnabler: enabler,
+		encoder:      encoder,
+		writer:       writer,
+		facility:     facility,
+		hostname:     syslogHeaderValue(hostname, syslogHostnameSize),
+		appName:      syslogHeaderValue(appName, syslogAppNameSize),
+		procID:       strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core { //nolint:ireturn
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+
+	for ind := range fields {
+		fields[ind].AddTo(clone.encoder)
+	}
+
+	return &clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buff, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return fmt.Errorf("encode entry: %w", err)
+	}
+
+	defer buff.Free()
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(
+		&msg,
+		"<%d>%d %s %s %s %s %s %s ",
+		c.facility<<syslogFacilityBits|syslogSeverity(entry.Level),
+		syslogVersion,
+		entry.Time.Format(syslogTimeLayout),
+		c.hostname,
+		c.appName,
+		c.procID,
+		syslogHeaderValue(entry.LoggerName, syslogMsgIDSize),
+		syslogNilValue,
+	)
+	msg.Write(bytes.TrimRight(buff.Bytes(), "\n"))
+
+	if err := c.writer.write(msg.Bytes()); err != nil {
+		return fmt.Errorf("write syslog: %w", err)
+	}
+
+	return nil
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// Close closes the syslog connection, it is reopened by the next write.
+func (c *syslogCore) Close() error {
+	return c.writer.close()
+}
+
+// syslogHeaderValue keeps the printable ascii of a header field, empty values are sent as nil value.
+func syslogHeaderValue(value string, size int) string {
+	value = strings.Map(func(r rune) rune {
+		if r > ' ' && r <= '~' {
+			return r
+		}
+
+		return -1
+	}, value)
+
+	if value == "" {
+		return syslogNilValue
+	}
+
+	return value[:min(len(value), size)]
+}
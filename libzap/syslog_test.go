@@ -0,0 +1,162 @@
+package libzap_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+)
+
+var syslogPattern = regexp.MustCompile( //nolint:gochecknoglobals
+	`^<(\d+)>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ \S+ service \d+ (\S+) - (.*)$`,
+)
+
+func TestSyslogOutput(t *testing.T) {
+	t.Parallel()
+
+	unixAddr := filepath.Join(t.TempDir(), "log.sock")
+
+	unixConn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: unixAddr, Net: "unixgram"})
+	checkError(t, err)
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	checkError(t, err)
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	checkError(t, err)
+
+	t.Cleanup(func() {
+		_ = unixConn.Close()
+		_ = udpConn.Close()
+		_ = tcpListener.Close()
+	})
+
+	tcpMessages := make(chan string, 1)
+
+	go func() {
+		conn, err := tcpListener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		// octet counting framing: LEN SP MSG
+		reader := bufio.NewReader(conn)
+
+		size, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+
+		length, _ := strconv.Atoi(strings.TrimSpace(size))
+		msg := make([]byte, length)
+
+		if _, err := io.ReadFull(reader, msg); err == nil {
+			tcpMessages <- string(msg)
+		}
+	}()
+
+	for _, network := range []struct {
+		name    string
+		address string
+		read    func() string
+	}{
+		{"unix", unixAddr, func() string { return readPacket(t, unixConn) }},
+		{"udp", udpConn.LocalAddr().String(), func() string { return readPacket(t, udpConn) }},
+		{"tcp", tcpListener.Addr().String(), func() string { return <-tcpMessages }},
+	} {
+		cfg := &libzap.Config{
+			Preset:      libzap.PresetProduction,
+			Development: libzap.PresetConfig{},
+			Production: libzap.PresetConfig{
+				Encoding:       libzap.EncodingJSON,
+				JSONMessageKey: "msg",
+				Outputs:        map[libzap.OutputEnum]bool{libzap.OutputSyslog: true},
+				Syslog: libzap.SyslogConfig{
+					Network:  network.name,
+					Address:  network.address,
+					Facility: "local0",
+					AppName:  "",
+				},
+			},
+		}
+
+		logger, err := libzap.New("service", cfg, nil)
+		checkError(t, err)
+
+		logger.Named("api").Warn("syslog entry", zap.Int("id", 1))
+
+		match := syslogPattern.FindStringSubmatch(network.read())
+		if match == nil || match[1] != "132" || match[2] != "api" || match[3] != `{"msg":"syslog entry","id":1}` {
+			t.Fatalf("%s message: %q", network.name, match)
+		}
+	}
+}
+
+func TestSyslogOutputClose(t *testing.T) {
+	t.Parallel()
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	checkError(t, err)
+
+	t.Cleanup(func() { _ = tcpListener.Close() })
+
+	cfg := &libzap.Config{
+		Preset:      libzap.PresetProduction,
+		Development: libzap.PresetConfig{},
+		Production: libzap.PresetConfig{
+			Encoding:       libzap.EncodingJSON,
+			JSONMessageKey: "msg",
+			Outputs:        map[libzap.OutputEnum]bool{libzap.OutputSyslog: true},
+			Syslog: libzap.SyslogConfig{
+				Network:  "tcp",
+				Address:  tcpListener.Addr().String(),
+				Facility: "local0",
+				AppName:  "",
+			},
+		},
+	}
+
+	logger, _, closeLogger, err := libzap.NewWithClose("service", cfg, nil)
+	checkError(t, err)
+
+	logger.Warn("before close")
+
+	conn, err := tcpListener.Accept()
+	checkError(t, err)
+
+	defer conn.Close()
+
+	checkError(t, closeLogger())
+	checkError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// the message is read up to the end of the stream closed by the logger
+	data, err := io.ReadAll(conn)
+	checkError(t, err)
+
+	if !strings.Contains(string(data), "before close") {
+		t.Fatalf("syslog stream: %q", data)
+	}
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	checkError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buff := make([]byte, 1<<16)
+
+	n, _, err := conn.ReadFrom(buff)
+	checkError(t, err)
+
+	return string(buff[:n])
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"slices"
 
 	"github.com/grinderz/go-libs/libctx"
 	"go.uber.org/zap"
@@ -28,9 +29,9 @@ func GetFields(ctx context.Context) []zap.Field {
 	return fields
 }
 
-// WithContext gets the zap.Field's from a context and adds them to an existing logger.
+// WithContext gets the zap.Field's and the trace fields from a context and adds them to an existing logger.
 func WithContext(ctx context.Context, log *zap.Logger) *zap.Logger {
-	return log.With(GetFields(ctx)...)
+	return log.With(slices.Concat(GetFields(ctx), TraceFields(ctx))...)
 }
 
 // With adds fields to context and return a logger with the same fields added.
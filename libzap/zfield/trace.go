@@ -0,0 +1,26 @@
+package zfield
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceFields returns the trace_id and span_id of the span in the context or nil without a valid span.
+func TraceFields(ctx context.Context) []zap.Field {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String(TraceIDKey, spanCtx.TraceID().String()),
+		zap.String(SpanIDKey, spanCtx.SpanID().String()),
+	}
+}
@@ -0,0 +1,29 @@
+// Package ztest captures the logs written through libzap loggers for assertions in tests.
+package ztest
+
+import (
+	"testing"
+
+	"github.com/grinderz/go-libs/libzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// New returns a logger recording the entries enabled at level in the returned logs.
+func New(level zapcore.LevelEnabler) (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+
+	return zap.New(core), logs
+}
+
+// Replace sets an observed logger as the libzap global logger until the end of the test.
+// Tests calling it change global state and must not run in parallel.
+func Replace(tb testing.TB, level zapcore.LevelEnabler) *observer.ObservedLogs {
+	tb.Helper()
+
+	logger, logs := New(level)
+	tb.Cleanup(libzap.Replace(logger))
+
+	return logs
+}
@@ -0,0 +1,53 @@
+package libcpio
+
+import (
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=AlignEnum -linecomment -output align_enum_string.go
+type AlignEnum int //nolint:recvcheck
+
+const (
+	AlignUnknown AlignEnum = iota // unknown
+	AlignExact   AlignEnum = iota // exact
+	Align4       AlignEnum = iota // 4
+	Align512     AlignEnum = iota // 512
+)
+
+func (a *AlignEnum) SetValue(value string) error {
+	align := AlignFromString(value)
+	if align == AlignUnknown {
+		return liberrors.NewInvalidStringEntityError("cpio_align", value)
+	}
+
+	*a = align
+
+	return nil
+}
+
+func (a AlignEnum) MarshalText() ([]byte, error) {
+	if a == AlignUnknown {
+		return nil, liberrors.NewInvalidStringEntityError("cpio_align", a.String())
+	}
+
+	return []byte(a.String()), nil
+}
+
+func (a *AlignEnum) UnmarshalText(text []byte) error {
+	return a.SetValue(string(text))
+}
+
+func AlignFromString(value string) AlignEnum {
+	switch strings.ToLower(value) {
+	case "exact":
+		return AlignExact
+	case "4":
+		return Align4
+	case "512":
+		return Align512
+	default:
+		return AlignUnknown
+	}
+}
@@ -0,0 +1,387 @@
+package libcpio
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+	"github.com/grinderz/go-libs/libio"
+	"github.com/grinderz/go-libs/libzap"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+const firstInode = 721
+
+type fileStat struct {
+	dev       uint64
+	ino       uint64
+	nlink     uint64
+	uid       int
+	gid       int
+	rdevMajor int64
+	rdevMinor int64
+}
+
+type linkKey struct {
+	dev uint64
+	ino uint64
+}
+
+type buildItem struct {
+	entry  Entry
+	path   string
+	target string
+	link   *linkKey
+}
+
+// BuildImage builds the root tree archive and compresses it with the libio codec named
+// by BuildConfig.Compression, HeaderTypeCPIO writes the archive uncompressed.
+func BuildImage(dst io.Writer, root string, cfg *BuildConfig) error {
+	if cfg.Compression.IsCPIO() {
+		return BuildArchive(dst, root, cfg)
+	}
+
+	codec, err := libio.CodecByName(cfg.Compression.String())
+	if err != nil {
+		return fmt.Errorf("codec: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		err := BuildArchive(pipeWriter, root, cfg)
+		pipeWriter.CloseWithError(err)
+		done <- err
+	}()
+
+	packErr := libio.Pack(dst, pipeReader, codec, libio.DefaultLevel)
+	pipeReader.CloseWithError(packErr)
+
+	buildErr := <-done
+
+	if packErr != nil {
+		return fmt.Errorf("pack %s: %w", codec.Name(), packErr)
+	}
+
+	if buildErr != nil {
+		return fmt.Errorf("build archive: %w", buildErr)
+	}
+
+	return nil
+}
+
+// BuildArchive writes a newc archive of the root tree and the spec nodes to dst.
+// Members are sorted by name so the output only depends on the tree contents and the config,
+// hardlinked files share an inode and carry the data on the last link like GNU cpio does.
+func BuildArchive(dst io.Writer, root string, cfg *BuildConfig) error {
+	items, err := collectTree(root, cfg)
+	if err != nil {
+		return fmt.Errorf("collect tree: %w", err)
+	}
+
+	if cfg.DeviceSpec != "" {
+		if items, err = mergeSpec(items, cfg); err != nil {
+			return fmt.Errorf("merge spec: %w", err)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b *buildItem) int {
+		return strings.Compare(a.entry.Name, b.entry.Name)
+	})
+
+	assignInodes(items)
+
+	format := cfg.Format
+	if format == HeaderTypeUnknown {
+		format = HeaderTypeCPIO
+	}
+
+	if !format.IsCPIO() {
+		return liberrors.NewInvalidStringEntityError("cpio_format", format.String())
+	}
+
+	writer := NewWriterWithFormat(dst, format)
+
+	for _, item := range items {
+		if err := writeItem(writer, format, item); err != nil {
+			return zerr.Wrap(
+				fmt.Errorf("write item: %w", err),
+				zap.String("entry_name", item.entry.Name),
+			)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close writer: %w", err)
+	}
+
+	return nil
+}
+
+func collectTree(root string, cfg *BuildConfig) ([]*buildItem, error) {
+	var items []*buildItem
+
+	err := filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("relative path: %w", err)
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return fmt.Errorf("file info: %w", err)
+		}
+
+		item, err := newTreeItem(path, filepath.ToSlash(rel), info, cfg)
+		if err != nil {
+			return zerr.Wrap(err, zap.String("path", path))
+		}
+
+		items = append(items, item)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk dir: %w", err)
+	}
+
+	return items, nil
+}
+
+func newTreeItem(path, name string, info fs.FileInfo, cfg *BuildConfig) (*buildItem, error) {
+	item := &buildItem{
+		entry: Entry{
+			Name:  name,
+			Mode:  unixMode(info.Mode()),
+			Nlink: 1,
+			Mtime: info.ModTime().Unix(),
+		},
+		path:   path,
+		target: "",
+		link:   nil,
+	}
+
+	switch item.entry.FileType() {
+	case ModeRegular:
+		item.entry.Size = info.Size()
+	case ModeSymlink:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, fmt.Errorf("read link: %w", err)
+		}
+
+		item.target = target
+		item.entry.Size = int64(len(target))
+	case ModeDir:
+		item.entry.Nlink = 2
+	}
+
+	if stat, ok := sysStat(info); ok {
+		item.entry.UID, item.entry.GID = stat.uid, stat.gid
+		item.entry.RDevMajor, item.entry.RDevMinor = stat.rdevMajor, stat.rdevMinor
+
+		if item.entry.FileType() == ModeRegular && stat.nlink > 1 {
+			item.link = &linkKey{dev: stat.dev, ino: stat.ino}
+		}
+	}
+
+	if cfg.UID >= 0 {
+		item.entry.UID = cfg.UID
+	}
+
+	if cfg.GID >= 0 {
+		item.entry.GID = cfg.GID
+	}
+
+	if cfg.Mtime >= 0 {
+		item.entry.Mtime = cfg.Mtime
+	}
+
+	return item, nil
+}
+
+// mergeSpec adds the spec nodes, a spec node replaces the tree member with the same name.
+func mergeSpec(items []*buildItem, cfg *BuildConfig) ([]*buildItem, error) {
+	specFile, err := os.Open(cfg.DeviceSpec)
+	if err != nil {
+		return nil, fmt.Errorf("open spec: %w", err)
+	}
+
+	defer func() {
+		if err := specFile.Close(); err != nil {
+			zerr.Wrap(err).WithField(
+				zap.String("spec_path", cfg.DeviceSpec),
+			).LogError(libzap.Logger(), "spec file close failed")
+		}
+	}()
+
+	entries, err := ParseSpec(specFile)
+	if err != nil {
+		return nil, zerr.Wrap(
+			fmt.Errorf("parse spec: %w", err),
+			zap.String("spec_path", cfg.DeviceSpec),
+		)
+	}
+
+	for _, entry := range entries {
+		if cfg.Mtime >= 0 {
+			entry.Mtime = cfg.Mtime
+		}
+
+		items = slices.DeleteFunc(items, func(item *buildItem) bool {
+			return item.entry.Name == entry.Name
+		})
+		items = append(items, &buildItem{entry: entry, path: "", target: "", link: nil})
+	}
+
+	return items, nil
+}
+
+// assignInodes numbers the sorted items, links of one file share the inode and nlink,
+// only the last link keeps the data size.
+func assignInodes(items []*buildItem) {
+	links := make(map[linkKey][]*buildItem)
+
+	for _, item := range items {
+		if item.link != nil {
+			links[*item.link] = append(links[*item.link], item)
+		}
+	}
+
+	inodes := make(map[linkKey]int64, len(links))
+	nextInode := int64(firstInode)
+
+	for _, item := range items {
+		if item.link == nil {
+			item.entry.Inode = nextInode
+			nextInode++
+
+			continue
+		}
+
+		inode, ok := inodes[*item.link]
+		if !ok {
+			inode = nextInode
+			inodes[*item.link] = inode
+			nextInode++
+		}
+
+		group := links[*item.link]
+
+		item.entry.Inode = inode
+		item.entry.Nlink = len(group)
+
+		if item != group[len(group)-1] {
+			item.entry.Size = 0
+		}
+	}
+}
+
+func writeItem(writer *Writer, format HeaderTypeEnum, item *buildItem) error {
+	if format == HeaderTypeCRC {
+		check, err := itemChecksum(item)
+		if err != nil {
+			return fmt.Errorf("checksum: %w", err)
+		}
+
+		item.entry.Check = check
+	}
+
+	if err := writer.WriteHeader(&item.entry); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	switch {
+	case item.entry.Size == 0:
+		return nil
+	case item.entry.FileType() == ModeSymlink:
+		if _, err := writer.Write([]byte(item.target)); err != nil {
+			return fmt.Errorf("write link target: %w", err)
+		}
+
+		return nil
+	}
+
+	file, err := os.Open(item.path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			zerr.Wrap(err).WithField(
+				zap.String("path", item.path),
+			).LogError(libzap.Logger(), "member file close failed")
+		}
+	}()
+
+	if _, err := io.CopyN(writer, file, item.entry.Size); err != nil {
+		return fmt.Errorf("copy data: %w", err)
+	}
+
+	return nil
+}
+
+func itemChecksum(item *buildItem) (uint32, error) {
+	switch {
+	case item.entry.Size == 0:
+		return 0, nil
+	case item.entry.FileType() == ModeSymlink:
+		return Checksum([]byte(item.target)), nil
+	}
+
+	data, err := os.ReadFile(item.path)
+	if err != nil {
+		return 0, fmt.Errorf("read file: %w", err)
+	}
+
+	return Checksum(data), nil
+}
+
+func unixMode(mode fs.FileMode) int64 {
+	result := int64(mode.Perm())
+
+	if mode&fs.ModeSetuid != 0 {
+		result |= 0o4000
+	}
+
+	if mode&fs.ModeSetgid != 0 {
+		result |= 0o2000
+	}
+
+	if mode&fs.ModeSticky != 0 {
+		result |= 0o1000
+	}
+
+	switch {
+	case mode.IsDir():
+		return result | ModeDir
+	case mode&fs.ModeSymlink != 0:
+		return result | ModeSymlink
+	case mode&fs.ModeNamedPipe != 0:
+		return result | ModeFIFO
+	case mode&fs.ModeSocket != 0:
+		return result | ModeSocket
+	case mode&fs.ModeCharDevice != 0:
+		return result | ModeChar
+	case mode&fs.ModeDevice != 0:
+		return result | ModeBlock
+	default:
+		return result | ModeRegular
+	}
+}
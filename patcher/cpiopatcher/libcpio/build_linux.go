@@ -0,0 +1,25 @@
+package libcpio
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func sysStat(info fs.FileInfo) (fileStat, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStat{}, false
+	}
+
+	rdev := uint64(stat.Rdev) //nolint:unconvert
+
+	return fileStat{
+		dev:       uint64(stat.Dev), //nolint:unconvert
+		ino:       stat.Ino,
+		nlink:     uint64(stat.Nlink), //nolint:unconvert
+		uid:       int(stat.Uid),
+		gid:       int(stat.Gid),
+		rdevMajor: int64((rdev>>8)&0xfff | (rdev>>32)&^0xfff), //nolint:gosec,mnd
+		rdevMinor: int64(rdev&0xff | (rdev>>12)&^0xff),        //nolint:gosec,mnd
+	}, true
+}
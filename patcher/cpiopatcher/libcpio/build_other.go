@@ -0,0 +1,11 @@
+//go:build !linux
+
+package libcpio
+
+import (
+	"io/fs"
+)
+
+func sysStat(_ fs.FileInfo) (fileStat, bool) {
+	return fileStat{}, false
+}
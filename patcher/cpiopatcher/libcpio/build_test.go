@@ -0,0 +1,72 @@
+package libcpio_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+)
+
+func TestBuildImage(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	checkError(t, os.MkdirAll(filepath.Join(root, "bin"), 0o755))
+	checkError(t, os.WriteFile(filepath.Join(root, "bin", "busybox"), []byte("busybox"), 0o755))
+	checkError(t, os.Link(filepath.Join(root, "bin", "busybox"), filepath.Join(root, "bin", "sh")))
+	checkError(t, os.Symlink("bin/busybox", filepath.Join(root, "init")))
+
+	specPath := filepath.Join(t.TempDir(), "spec")
+	checkError(t, os.WriteFile(specPath, []byte("# devices\ndir /dev 0755 0 0\nnod /dev/console 0600 0 0 c 5 1\n"), 0o600))
+
+	cfg := &libcpio.BuildConfig{
+		UID:         0,
+		GID:         0,
+		Mtime:       0,
+		DeviceSpec:  specPath,
+		Compression: libcpio.HeaderTypeGZ,
+	}
+
+	var first, second bytes.Buffer
+
+	checkError(t, libcpio.BuildImage(&first, root, cfg))
+	checkError(t, libcpio.BuildImage(&second, root, cfg))
+
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("build is not deterministic")
+	}
+
+	layout, err := libcpio.Inspect(bytes.NewReader(first.Bytes()), testMaxDecompressBytes)
+	checkError(t, err)
+
+	members := layout.Segments[0].Members
+	names := make([]string, 0, len(members))
+
+	for _, member := range members {
+		names = append(names, member.Name)
+	}
+
+	expected := []string{"bin", "bin/busybox", "bin/sh", "dev", "dev/console", "init"}
+	if len(names) != len(expected) {
+		t.Fatalf("members: %v != %v", names, expected)
+	}
+
+	for ind := range expected {
+		if names[ind] != expected[ind] {
+			t.Fatalf("members: %v != %v", names, expected)
+		}
+	}
+
+	busybox, sh, console := members[1], members[2], members[4]
+
+	if busybox.Inode != sh.Inode || busybox.Nlink != 2 || busybox.Size != 0 || sh.Size != int64(len("busybox")) {
+		t.Fatalf("hardlinks: %+v %+v", busybox, sh)
+	}
+
+	if console.FileType() != libcpio.ModeChar || console.RDevMajor != 5 || console.RDevMinor != 1 {
+		t.Fatalf("console: %+v", console)
+	}
+}
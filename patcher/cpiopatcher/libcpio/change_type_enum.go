@@ -0,0 +1,53 @@
+package libcpio
+
+import (
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=ChangeTypeEnum -linecomment -output change_type_enum_string.go
+type ChangeTypeEnum int //nolint:recvcheck
+
+const (
+	ChangeTypeUnknown  ChangeTypeEnum = iota // unknown
+	ChangeTypeAdded    ChangeTypeEnum = iota // added
+	ChangeTypeRemoved  ChangeTypeEnum = iota // removed
+	ChangeTypeModified ChangeTypeEnum = iota // modified
+)
+
+func (ct *ChangeTypeEnum) SetValue(value string) error {
+	changeType := ChangeTypeFromString(value)
+	if changeType == ChangeTypeUnknown {
+		return liberrors.NewInvalidStringEntityError("cpio_change_type", value)
+	}
+
+	*ct = changeType
+
+	return nil
+}
+
+func (ct ChangeTypeEnum) MarshalText() ([]byte, error) {
+	if ct == ChangeTypeUnknown {
+		return nil, liberrors.NewInvalidStringEntityError("cpio_change_type", ct.String())
+	}
+
+	return []byte(ct.String()), nil
+}
+
+func (ct *ChangeTypeEnum) UnmarshalText(text []byte) error {
+	return ct.SetValue(string(text))
+}
+
+func ChangeTypeFromString(value string) ChangeTypeEnum {
+	switch strings.ToLower(value) {
+	case "added":
+		return ChangeTypeAdded
+	case "removed":
+		return ChangeTypeRemoved
+	case "modified":
+		return ChangeTypeModified
+	default:
+		return ChangeTypeUnknown
+	}
+}
@@ -0,0 +1,126 @@
+package libcpio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+const newcCheckOffset = newcHeaderSize - newcFieldSize
+
+// Checksum returns the crc format checksum, the 32-bit sum of all data bytes.
+func Checksum(data []byte) uint32 {
+	var sum uint32
+
+	for _, b := range data {
+		sum += uint32(b)
+	}
+
+	return sum
+}
+
+// RecomputeChecksums rewrites in place the check field of every crc member whose data no longer
+// matches it, e.g. after the archive was patched. Concatenated archives are handled, members of
+// other formats are left untouched and the file is only written when a crc member needs a fix.
+// Like Inspect, an archive without a trailer ends the image. It returns the number of fixed members.
+func RecomputeChecksums(file *os.File) (int, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("file seek: %w", err)
+	}
+
+	counter := &countingReader{reader: bufio.NewReader(file), count: 0}
+
+	var fixes []checksumFix
+
+	for trailer := true; trailer; {
+		if err := skipZeros(counter); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return 0, fmt.Errorf("skip archive padding: %w", err)
+		}
+
+		archiveFixes, archiveTrailer, err := recomputeArchiveChecksums(NewReader(counter), counter.count)
+		if err != nil {
+			return 0, zerr.Wrap(
+				fmt.Errorf("recompute archive: %w", err),
+				zap.Int("fixes", len(fixes)),
+			)
+		}
+
+		fixes = append(fixes, archiveFixes...)
+		trailer = archiveTrailer
+	}
+
+	for _, fix := range fixes {
+		if _, err := file.WriteAt(fmt.Appendf(nil, "%08X", fix.check), fix.offset); err != nil {
+			return 0, zerr.Wrap(
+				fmt.Errorf("write check: %w", err),
+				zap.String("entry_name", fix.name),
+			)
+		}
+	}
+
+	return len(fixes), nil
+}
+
+// checksumFix is the check of a crc member to write at offset.
+type checksumFix struct {
+	name   string
+	offset int64
+	check  uint32
+}
+
+// recomputeArchiveChecksums returns the fixes of the crc members of one archive and whether it
+// ended with a trailer.
+func recomputeArchiveChecksums(rdr *Reader, base int64) ([]checksumFix, bool, error) {
+	var (
+		fixes   []checksumFix
+		entries int
+	)
+
+	for {
+		entry, err := rdr.Next()
+		if err != nil {
+			if entries > 0 && isArchiveEnd(err) {
+				return fixes, false, nil
+			}
+
+			return nil, false, zerr.Wrap(
+				fmt.Errorf("next entry: %w", err),
+				zap.Int("entries_read", entries),
+			)
+		}
+
+		if entry.IsTrailer() {
+			return fixes, true, nil
+		}
+
+		entries++
+
+		if entry.Format != HeaderTypeCRC {
+			continue
+		}
+
+		if _, err := io.Copy(io.Discard, rdr); err != nil {
+			return nil, false, zerr.Wrap(
+				fmt.Errorf("read data: %w", err),
+				zap.String("entry_name", entry.Name),
+			)
+		}
+
+		if rdr.Sum() != entry.Check {
+			fixes = append(fixes, checksumFix{
+				name:   entry.Name,
+				offset: base + entry.Offset + newcCheckOffset,
+				check:  rdr.Sum(),
+			})
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package libcpio_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+)
+
+func TestFormatsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "init"), []byte("#!/bin/sh\n"), 0o755))
+
+	for _, format := range []libcpio.HeaderTypeEnum{libcpio.HeaderTypeODC, libcpio.HeaderTypeCRC} {
+		var archive bytes.Buffer
+
+		checkError(t, libcpio.BuildImage(&archive, root, &libcpio.BuildConfig{
+			UID:         0,
+			GID:         0,
+			Mtime:       0,
+			DeviceSpec:  "",
+			Format:      format,
+			Compression: libcpio.HeaderTypeCPIO,
+		}))
+
+		headerType, err := libcpio.HeaderTypeFromReader(bytes.NewReader(archive.Bytes()))
+		checkError(t, err)
+
+		if headerType != format {
+			t.Fatalf("header type: %s != %s", headerType, format)
+		}
+
+		layout, err := libcpio.Inspect(bytes.NewReader(archive.Bytes()), testMaxDecompressBytes)
+		checkError(t, err)
+
+		members := layout.Segments[0].Members
+		if len(members) != 1 || members[0].Name != "init" || members[0].Format != format {
+			t.Fatalf("%s members: %+v", format, members)
+		}
+	}
+}
+
+func TestRecomputeChecksums(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "init"), []byte("#!/bin/sh\n"), 0o755))
+	checkError(t, os.WriteFile(filepath.Join(root, "lib"), []byte("library"), 0o644))
+
+	var archive bytes.Buffer
+
+	checkError(t, libcpio.BuildArchive(&archive, root, &libcpio.BuildConfig{
+		UID:         0,
+		GID:         0,
+		Mtime:       0,
+		DeviceSpec:  "",
+		Format:      libcpio.HeaderTypeCRC,
+		Compression: libcpio.HeaderTypeCPIO,
+	}))
+
+	patched := bytes.Replace(archive.Bytes(), []byte("library"), []byte("LIBRARY"), 1)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "raw"))
+	checkError(t, err)
+
+	defer file.Close()
+
+	_, err = file.Write(patched)
+	checkError(t, err)
+
+	fixed, err := libcpio.RecomputeChecksums(file)
+	checkError(t, err)
+
+	if fixed != 1 {
+		t.Fatalf("fixed: %d != 1", fixed)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	checkError(t, err)
+
+	rdr := libcpio.NewReader(file)
+
+	for {
+		entry, err := rdr.Next()
+		checkError(t, err)
+
+		if entry.IsTrailer() {
+			break
+		}
+
+		_, err = io.Copy(io.Discard, rdr)
+		checkError(t, err)
+
+		if rdr.Sum() != entry.Check {
+			t.Fatalf("%s checksum: %08X != %08X", entry.Name, rdr.Sum(), entry.Check)
+		}
+	}
+}
+
+func TestRecomputeChecksumsConcatenated(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "lib"), []byte("library"), 0o644))
+
+	var image bytes.Buffer
+
+	for _, format := range []libcpio.HeaderTypeEnum{libcpio.HeaderTypeCPIO, libcpio.HeaderTypeCRC} {
+		checkError(t, libcpio.BuildArchive(&image, root, &libcpio.BuildConfig{
+			UID:         0,
+			GID:         0,
+			Mtime:       0,
+			DeviceSpec:  "",
+			Format:      format,
+			Compression: libcpio.HeaderTypeCPIO,
+		}))
+	}
+
+	// patch both archives, only the crc one carries checksums
+	patched := bytes.ReplaceAll(image.Bytes(), []byte("library"), []byte("LIBRARY"))
+	file := writeTempFile(t, "raw", patched)
+
+	fixed, err := libcpio.RecomputeChecksums(file)
+	checkError(t, err)
+
+	if fixed != 1 {
+		t.Fatalf("fixed: %d != 1", fixed)
+	}
+}
+
+func TestRecomputeChecksumsTrailerless(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "lib"), []byte("library"), 0o644))
+
+	for _, test := range []struct {
+		format libcpio.HeaderTypeEnum
+		fixed  int
+	}{
+		{libcpio.HeaderTypeCPIO, 0},
+		{libcpio.HeaderTypeCRC, 1},
+	} {
+		var archive bytes.Buffer
+
+		checkError(t, libcpio.BuildArchive(&archive, root, &libcpio.BuildConfig{
+			UID:         0,
+			GID:         0,
+			Mtime:       0,
+			DeviceSpec:  "",
+			Format:      test.format,
+			Compression: libcpio.HeaderTypeCPIO,
+		}))
+
+		// cut the trailer header, 110 bytes before its name
+		trailerless := archive.Bytes()[:bytes.Index(archive.Bytes(), []byte(libcpio.TrailerName))-110]
+		patched := bytes.Replace(trailerless, []byte("library"), []byte("LIBRARY"), 1)
+		file := writeTempFile(t, "raw", patched)
+
+		fixed, err := libcpio.RecomputeChecksums(file)
+		checkError(t, err)
+
+		if fixed != test.fixed {
+			t.Fatalf("%s fixed: %d != %d", test.format, fixed, test.fixed)
+		}
+
+		data, err := os.ReadFile(file.Name())
+		checkError(t, err)
+
+		if test.fixed == 0 && !bytes.Equal(data, patched) {
+			t.Fatalf("%s rewritten without crc members", test.format)
+		}
+	}
+}
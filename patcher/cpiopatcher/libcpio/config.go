@@ -0,0 +1,10 @@
+package libcpio
+
+type BuildConfig struct {
+	UID         int            `yaml:"uid"         env:"UID"         env-default:"-1"   env-description:"Override the owner uid of all tree members, -1 keeps the source uid."`
+	GID         int            `yaml:"gid"         env:"GID"         env-default:"-1"   env-description:"Override the owner gid of all tree members, -1 keeps the source gid."`
+	Mtime       int64          `yaml:"mtime"       env:"MTIME"       env-default:"-1"   env-description:"Override the mtime of all members, -1 keeps the source mtime."`
+	DeviceSpec  string         `yaml:"deviceSpec"  env:"DEVICE_SPEC" env-default:""     env-description:"Path to a gen_init_cpio style spec file with extra nodes (dir, nod, pipe, sock)."`
+	Format      HeaderTypeEnum `yaml:"format"      env:"FORMAT"      env-default:"cpio" env-description:"Set the archive format (cpio, crc, odc)."`
+	Compression HeaderTypeEnum `yaml:"compression" env:"COMPRESSION" env-default:"gz"   env-description:"Set the image compression (cpio, gz, xz)."`
+}
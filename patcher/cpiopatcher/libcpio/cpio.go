@@ -1,119 +1,181 @@
 package libcpio
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
 
-	cpio "github.com/grinderz/gocpio"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
 )
 
 const zeroByte = 0x00
 
-func findZeroFooterSize(inFile *os.File, buffSize int) (int64, error) {
-	buff := make([]byte, buffSize)
+// HeaderSegment is a plain cpio archive at the start of an image with the zero padding following it.
+type HeaderSegment struct {
+	Offset      int64
+	Size        int64
+	ZeroPadding int64
+	Trailer     bool
+}
 
-	var (
-		index     int64
-		readBytes int
-		totalRead int64
-		err       error
-	)
+// HeaderLayout describes the plain cpio segments preceding the compressed payload of an image.
+// Size covers the segments with the padding between them, ZeroFooter is the padding before the payload.
+// PayloadType is HeaderTypeUnknown when only zeros follow the segments.
+type HeaderLayout struct {
+	Size        int64
+	ZeroFooter  int64
+	Segments    []HeaderSegment
+	PayloadType HeaderTypeEnum
+}
 
-	for {
-		if readBytes, err = inFile.Read(buff); err != nil && err != io.EOF {
-			return 0, fmt.Errorf("read file: %w", err)
-		}
+func WriteHeader(dst io.Writer, reader io.Reader, footerSize int64) error {
+	if _, err := io.Copy(dst, reader); err != nil {
+		return fmt.Errorf("stream copy: %w", err)
+	}
+
+	if _, err := dst.Write(make([]byte, footerSize)); err != nil {
+		return fmt.Errorf("write to writer: %w", err)
+	}
+
+	return nil
+}
 
-		totalRead += int64(readBytes)
+// WriteHeaderLayout writes the cut header back followed by the zero footer, AlignExact reproduces
+// the footer of the layout, Align4 and Align512 pad the header to the given boundary instead.
+func WriteHeaderLayout(dst io.Writer, reader io.Reader, layout *HeaderLayout, align AlignEnum) error {
+	written, err := io.Copy(dst, reader)
+	if err != nil {
+		return fmt.Errorf("stream copy: %w", err)
+	}
 
-		for _, b := range buff {
-			if b != zeroByte {
-				if _, err := inFile.Seek(-totalRead+index, 1); err != nil {
-					return 0, fmt.Errorf("file seek: %w", err)
-				}
+	var footerSize int64
+
+	switch align {
+	case Align4:
+		footerSize = padding(written, newcAlign)
+	case Align512:
+		footerSize = padding(written, trailerAlign)
+	case AlignUnknown:
+		fallthrough
+	case AlignExact:
+		footerSize = layout.ZeroFooter
+	}
 
-				return index, nil
-			}
+	if _, err := dst.Write(make([]byte, footerSize)); err != nil {
+		return fmt.Errorf("write to writer: %w", err)
+	}
 
-			index++
-		}
+	return nil
+}
 
-		if err == io.EOF {
-			return 0, fmt.Errorf("read file EOF: %w", err)
-		}
+func CutHeader(inFile, cpioFile *os.File, bufferSize int) (HeaderTypeEnum, int64, error) {
+	layout, err := CutHeaderLayout(inFile, cpioFile, bufferSize)
+	if err != nil {
+		return HeaderTypeUnknown, 0, err
 	}
+
+	return layout.PayloadType, layout.ZeroFooter, nil
 }
 
-func findTrailer(file *os.File) (int64, error) {
-	rdr := cpio.NewReader(file)
+// CutHeaderLayout copies all plain cpio segments from the start of inFile to cpioFile and reports
+// their layout. A following cpio segment must start 4 bytes aligned as the kernel requires, anything
+// else ends the header. On return inFile is positioned right after the payload magic.
+func CutHeaderLayout(inFile, cpioFile *os.File, bufferSize int) (*HeaderLayout, error) {
+	size, err := inFile.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("in file seek: %w", err)
+	}
 
-	var (
-		hdr *cpio.Header
-		err error
-	)
+	layout := &HeaderLayout{
+		Size:        0,
+		ZeroFooter:  0,
+		Segments:    nil,
+		PayloadType: HeaderTypeUnknown,
+	}
 
-	for {
-		hdr, err = rdr.Next()
+	for offset := int64(0); ; {
+		segment, err := scanHeaderSegment(inFile, offset, bufferSize)
 		if err != nil {
-			return 0, fmt.Errorf("cpio reader: %w", err)
+			return nil, zerr.Wrap(
+				fmt.Errorf("scan cpio: %w", err),
+				zap.Int64("offset", offset),
+			)
 		}
 
-		if hdr.Name == "TRAILER!!!" {
+		layout.Segments = append(layout.Segments, *segment)
+
+		next := segment.Offset + segment.Size + segment.ZeroPadding
+		if next >= size {
 			break
 		}
-	}
 
-	if _, err := file.Seek(0, 0); err != nil {
-		return 0, fmt.Errorf("cpio seek: %w", err)
-	}
+		nextType, err := headerTypeAt(inFile, next)
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("recognize header type: %w", err),
+				zap.Int64("offset", next),
+			)
+		}
 
-	return rdr.Pos(), nil
-}
+		if !nextType.IsCPIO() || padding(next, newcAlign) != 0 {
+			layout.PayloadType = nextType
+			break
+		}
 
-func cut(dst io.Writer, src *os.File) error {
-	if _, err := src.Seek(0, 0); err != nil {
-		return fmt.Errorf("src seek: %w", err)
+		offset = next
 	}
 
-	i, err := findTrailer(src)
-	if err != nil {
-		return fmt.Errorf("find trailer: %w", err)
-	}
+	last := layout.Segments[len(layout.Segments)-1]
+	layout.Size = last.Offset + last.Size
+	layout.ZeroFooter = last.ZeroPadding
 
-	if _, err = io.CopyN(dst, src, i); err != nil {
-		return fmt.Errorf("CopyN: %w", err)
+	if _, err := inFile.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("in file seek: %w", err)
 	}
 
-	return nil
-}
-
-func WriteHeader(dst io.Writer, reader io.Reader, footerSize int64) error {
-	if _, err := io.Copy(dst, reader); err != nil {
-		return fmt.Errorf("stream copy: %w", err)
+	if _, err := io.CopyN(cpioFile, inFile, layout.Size); err != nil {
+		return nil, fmt.Errorf("cut cpio: %w", err)
 	}
 
-	if _, err := dst.Write(make([]byte, footerSize)); err != nil {
-		return fmt.Errorf("write to writer: %w", err)
+	payloadMagicEnd := min(layout.Size+layout.ZeroFooter+MaxMagicSize, size)
+	if _, err := inFile.Seek(payloadMagicEnd, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("in file seek: %w", err)
 	}
 
-	return nil
+	return layout, nil
 }
 
-func CutHeader(inFile, cpioFile *os.File, bufferSize int) (HeaderTypeEnum, int64, error) {
-	if err := cut(cpioFile, inFile); err != nil {
-		return HeaderTypeUnknown, 0, fmt.Errorf("cut cpio: %w", err)
+func scanHeaderSegment(inFile *os.File, offset int64, bufferSize int) (*HeaderSegment, error) {
+	if _, err := inFile.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("in file seek: %w", err)
 	}
 
-	cpioZeroFooterSize, err := findZeroFooterSize(inFile, bufferSize)
+	counter := &countingReader{reader: bufio.NewReaderSize(inFile, bufferSize), count: 0}
+
+	_, end, trailer, err := readArchive(NewReader(counter), 0, nil, nil)
 	if err != nil {
-		return HeaderTypeUnknown, 0, fmt.Errorf("find zero footer size: %w", err)
+		return nil, fmt.Errorf("read archive: %w", err)
 	}
 
-	fileType, err := HeaderTypeFromReader(inFile)
+	zeroPadding, err := countZeros(inFile, offset+end, bufferSize)
 	if err != nil {
-		return HeaderTypeUnknown, 0, fmt.Errorf("recognize header type: %w", err)
+		return nil, fmt.Errorf("count zero padding: %w", err)
+	}
+
+	return &HeaderSegment{
+		Offset:      offset,
+		Size:        end,
+		ZeroPadding: zeroPadding,
+		Trailer:     trailer,
+	}, nil
+}
+
+func headerTypeAt(reader io.ReadSeeker, offset int64) (HeaderTypeEnum, error) {
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return HeaderTypeUnknown, fmt.Errorf("seek: %w", err)
 	}
 
-	return fileType, cpioZeroFooterSize, nil
+	return HeaderTypeFromReader(reader)
 }
@@ -0,0 +1,100 @@
+package libcpio_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+)
+
+func TestCutHeaderLayout(t *testing.T) {
+	t.Parallel()
+
+	amd := buildCpio(t, []testFile{{"kernel/x86/microcode/AuthenticAMD.bin", "amd"}})
+	intel := buildCpio(t, []testFile{{"kernel/x86/microcode/GenuineIntel.bin", "intel"}})
+	payload := gzipBytes(t, buildCpio(t, []testFile{{"init", "init"}}))
+
+	var image bytes.Buffer
+
+	image.Write(amd)
+	image.Write(make([]byte, 8))
+	image.Write(intel)
+	image.Write(make([]byte, 3))
+	image.Write(payload)
+
+	inFile := writeTempFile(t, "image", image.Bytes())
+	cpioFile := writeTempFile(t, "cpio", nil)
+
+	layout, err := libcpio.CutHeaderLayout(inFile, cpioFile, 16)
+	checkError(t, err)
+
+	if len(layout.Segments) != 2 || layout.PayloadType != libcpio.HeaderTypeGZ {
+		t.Fatalf("layout: %+v", layout)
+	}
+
+	headerSize := int64(image.Len() - len(payload))
+	if layout.Segments[1].Offset != int64(len(amd)+8) || layout.Size+layout.ZeroFooter != headerSize {
+		t.Fatalf("layout sizes: %+v", layout)
+	}
+
+	_, err = cpioFile.Seek(0, io.SeekStart)
+	checkError(t, err)
+
+	var rebuilt bytes.Buffer
+
+	checkError(t, libcpio.WriteHeaderLayout(&rebuilt, cpioFile, layout, libcpio.AlignExact))
+	rebuilt.Write(payload)
+
+	if !bytes.Equal(rebuilt.Bytes(), image.Bytes()) {
+		t.Fatal("rebuilt image differs")
+	}
+}
+
+func TestCutHeaderLayoutTrailingPadding(t *testing.T) {
+	t.Parallel()
+
+	archive := buildCpio(t, []testFile{{"init", "init"}})
+	trailerless := archive[:bytes.Index(archive, []byte(libcpio.TrailerName))-110]
+
+	for name, data := range map[string][]byte{
+		"padding":     append(bytes.Clone(archive), make([]byte, 1024)...),
+		"trailerless": append(bytes.Clone(trailerless), make([]byte, 7)...),
+	} {
+		inFile := writeTempFile(t, name, data)
+		cpioFile := writeTempFile(t, name+".cpio", nil)
+
+		layout, err := libcpio.CutHeaderLayout(inFile, cpioFile, 16)
+		checkError(t, err)
+
+		if layout.PayloadType != libcpio.HeaderTypeUnknown || len(layout.Segments) != 1 {
+			t.Fatalf("%s layout: %+v", name, layout)
+		}
+
+		if layout.Size+layout.ZeroFooter != int64(len(data)) {
+			t.Fatalf("%s layout does not cover the image: %+v", name, layout)
+		}
+
+		if layout.Segments[0].Trailer != (name == "padding") {
+			t.Fatalf("%s trailer: %+v", name, layout.Segments[0])
+		}
+	}
+}
+
+func writeTempFile(t *testing.T, name string, data []byte) *os.File {
+	t.Helper()
+
+	file, err := os.Create(filepath.Join(t.TempDir(), name))
+	checkError(t, err)
+
+	t.Cleanup(func() {
+		checkError(t, file.Close())
+	})
+
+	_, err = file.Write(data)
+	checkError(t, err)
+
+	return file
+}
@@ -0,0 +1,176 @@
+package libcpio
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+type ByteRange struct {
+	Offset int64
+	Size   int64
+}
+
+type MetadataChange struct {
+	Field string
+	Old   int64
+	New   int64
+}
+
+type Change struct {
+	Name     string
+	Type     ChangeTypeEnum
+	Old      *Entry
+	New      *Entry
+	Metadata []MetadataChange
+	Ranges   []ByteRange
+}
+
+type Diff struct {
+	Old     *Layout
+	New     *Layout
+	Changes []Change
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+type member struct {
+	entry Entry
+	data  []byte
+}
+
+// DiffImages decompresses both images and compares their members by name.
+// Member contents are held in memory, for duplicate names the last one wins as the kernel extracts them in order.
+func DiffImages(oldImage, newImage io.ReadSeeker, maxDecompressBytes int64) (*Diff, error) {
+	oldLayout, oldMembers, err := collectMembers(oldImage, maxDecompressBytes)
+	if err != nil {
+		return nil, fmt.Errorf("collect old members: %w", err)
+	}
+
+	newLayout, newMembers, err := collectMembers(newImage, maxDecompressBytes)
+	if err != nil {
+		return nil, fmt.Errorf("collect new members: %w", err)
+	}
+
+	names := make([]string, 0, len(oldMembers)+len(newMembers))
+
+	for name := range oldMembers {
+		names = append(names, name)
+	}
+
+	for name := range newMembers {
+		if _, ok := oldMembers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	diff := &Diff{
+		Old:     oldLayout,
+		New:     newLayout,
+		Changes: nil,
+	}
+
+	for _, name := range names {
+		oldMember, oldOk := oldMembers[name]
+		newMember, newOk := newMembers[name]
+
+		switch {
+		case !oldOk:
+			diff.Changes = append(diff.Changes, newChange(name, ChangeTypeAdded, nil, &newMember.entry))
+		case !newOk:
+			diff.Changes = append(diff.Changes, newChange(name, ChangeTypeRemoved, &oldMember.entry, nil))
+		default:
+			change := newChange(name, ChangeTypeModified, &oldMember.entry, &newMember.entry)
+			change.Metadata = compareMetadata(&oldMember.entry, &newMember.entry)
+			change.Ranges = compareBytes(oldMember.data, newMember.data)
+
+			if len(change.Metadata) > 0 || len(change.Ranges) > 0 {
+				diff.Changes = append(diff.Changes, change)
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+func newChange(name string, changeType ChangeTypeEnum, oldEntry, newEntry *Entry) Change {
+	return Change{
+		Name:     name,
+		Type:     changeType,
+		Old:      oldEntry,
+		New:      newEntry,
+		Metadata: nil,
+		Ranges:   nil,
+	}
+}
+
+func collectMembers(image io.ReadSeeker, maxDecompressBytes int64) (*Layout, map[string]*member, error) {
+	members := make(map[string]*member)
+
+	layout, err := Walk(image, maxDecompressBytes, func(_ *Segment, entry *Entry, data io.Reader) error {
+		buff, err := io.ReadAll(data)
+		if err != nil {
+			return fmt.Errorf("read data: %w", err)
+		}
+
+		members[entry.Name] = &member{entry: *entry, data: buff}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("walk: %w", err)
+	}
+
+	return layout, members, nil
+}
+
+// compareMetadata skips inode, nlink and device fields which depend on the filesystem the archive was built on.
+func compareMetadata(oldEntry, newEntry *Entry) []MetadataChange {
+	fields := []MetadataChange{
+		{"mode", oldEntry.Mode, newEntry.Mode},
+		{"uid", int64(oldEntry.UID), int64(newEntry.UID)},
+		{"gid", int64(oldEntry.GID), int64(newEntry.GID)},
+		{"mtime", oldEntry.Mtime, newEntry.Mtime},
+		{"size", oldEntry.Size, newEntry.Size},
+		{"rdev_major", oldEntry.RDevMajor, newEntry.RDevMajor},
+		{"rdev_minor", oldEntry.RDevMinor, newEntry.RDevMinor},
+	}
+
+	var changes []MetadataChange
+
+	for _, field := range fields {
+		if field.Old != field.New {
+			changes = append(changes, field)
+		}
+	}
+
+	return changes
+}
+
+// compareBytes returns the ranges where the contents differ, bytes past the end of the shorter one always differ.
+func compareBytes(oldData, newData []byte) []ByteRange {
+	var (
+		ranges []ByteRange
+		start  = -1
+	)
+
+	size := max(len(oldData), len(newData))
+
+	for ind := range size + 1 {
+		differs := ind < size && (ind >= len(oldData) || ind >= len(newData) || oldData[ind] != newData[ind])
+
+		switch {
+		case differs && start < 0:
+			start = ind
+		case !differs && start >= 0:
+			ranges = append(ranges, ByteRange{Offset: int64(start), Size: int64(ind - start)})
+			start = -1
+		}
+	}
+
+	return ranges
+}
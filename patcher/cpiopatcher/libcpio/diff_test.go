@@ -0,0 +1,53 @@
+package libcpio_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+)
+
+func TestDiffImages(t *testing.T) {
+	t.Parallel()
+
+	oldImage := gzipBytes(t, buildCpio(t, []testFile{
+		{"bin/busybox", "0123456789"},
+		{"etc/removed", "gone"},
+		{"init", "same"},
+	}))
+	newImage := gzipBytes(t, buildCpio(t, []testFile{
+		{"bin/busybox", "01xx4567890"},
+		{"etc/added", "new"},
+		{"init", "same"},
+	}))
+
+	diff, err := libcpio.DiffImages(bytes.NewReader(oldImage), bytes.NewReader(newImage), testMaxDecompressBytes)
+	checkError(t, err)
+
+	if len(diff.Changes) != 3 {
+		t.Fatalf("changes count: %d != 3: %+v", len(diff.Changes), diff.Changes)
+	}
+
+	modified, added, removed := diff.Changes[0], diff.Changes[1], diff.Changes[2]
+
+	if added.Name != "etc/added" || added.Type != libcpio.ChangeTypeAdded {
+		t.Fatalf("added: %+v", added)
+	}
+
+	if removed.Name != "etc/removed" || removed.Type != libcpio.ChangeTypeRemoved {
+		t.Fatalf("removed: %+v", removed)
+	}
+
+	if modified.Name != "bin/busybox" || modified.Type != libcpio.ChangeTypeModified {
+		t.Fatalf("modified: %+v", modified)
+	}
+
+	if len(modified.Metadata) != 1 || modified.Metadata[0].Field != "size" {
+		t.Fatalf("modified metadata: %+v", modified.Metadata)
+	}
+
+	expected := []libcpio.ByteRange{{Offset: 2, Size: 2}, {Offset: 10, Size: 1}}
+	if len(modified.Ranges) != len(expected) || modified.Ranges[0] != expected[0] || modified.Ranges[1] != expected[1] {
+		t.Fatalf("modified ranges: %+v != %+v", modified.Ranges, expected)
+	}
+}
@@ -0,0 +1,70 @@
+package libcpio
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidMagic = errors.New("cpio invalid magic")
+	ErrWriteTooLong = errors.New("cpio write too long")
+)
+
+type checksumMismatchError struct {
+	name     string
+	expected uint32
+	actual   uint32
+}
+
+func (e *checksumMismatchError) Error() string {
+	return fmt.Sprintf(
+		"%s: cpio checksum mismatch: expected[%08X] actual[%08X]",
+		e.name,
+		e.expected,
+		e.actual,
+	)
+}
+
+func newChecksumMismatchError(name string, expected, actual uint32) error {
+	return zerr.Wrap(
+		&checksumMismatchError{
+			name:     name,
+			expected: expected,
+			actual:   actual,
+		},
+		zap.String("entry_name", name),
+		zap.Uint32("expected_checksum", expected),
+		zap.Uint32("actual_checksum", actual),
+	)
+}
+
+type odcFieldOverflowError struct {
+	name       string
+	fieldIndex int
+	value      int64
+}
+
+func (e *odcFieldOverflowError) Error() string {
+	return fmt.Sprintf(
+		"%s: cpio odc field %d overflow: %d",
+		e.name,
+		e.fieldIndex,
+		e.value,
+	)
+}
+
+func newODCFieldOverflowError(name string, fieldIndex int, value int64) error {
+	return zerr.Wrap(
+		&odcFieldOverflowError{
+			name:       name,
+			fieldIndex: fieldIndex,
+			value:      value,
+		},
+		zap.String("entry_name", name),
+		zap.Int("field_index", fieldIndex),
+		zap.Int64("field_value", value),
+	)
+}
@@ -18,6 +18,14 @@
 		0x30, 0x37, 0x30, 0x37, 0x30, 0x31,
 	}
 
+	odcMagic = []byte{ //nolint:gochecknoglobals
+		0x30, 0x37, 0x30, 0x37, 0x30, 0x37,
+	}
+
+	crcMagic = []byte{ //nolint:gochecknoglobals
+		0x30, 0x37, 0x30, 0x37, 0x30, 0x32,
+	}
+
 	xzMagic = []byte{ //nolint:gochecknoglobals
 		0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00,
 	}
@@ -35,6 +43,8 @@
 	HeaderTypeCPIO    HeaderTypeEnum = iota // cpio
 	HeaderTypeXZ      HeaderTypeEnum = iota // xz
 	HeaderTypeGZ      HeaderTypeEnum = iota // gz
+	HeaderTypeODC     HeaderTypeEnum = iota // odc
+	HeaderTypeCRC     HeaderTypeEnum = iota // crc
 )
 
 func (ht *HeaderTypeEnum) SetValue(value string) error {
@@ -60,6 +70,11 @@ func (ht *HeaderTypeEnum) UnmarshalText(text []byte) error {
 	return ht.SetValue(string(text))
 }
 
+// IsCPIO reports whether the header type is one of the cpio formats (newc, odc, crc).
+func (ht HeaderTypeEnum) IsCPIO() bool {
+	return ht == HeaderTypeCPIO || ht == HeaderTypeODC || ht == HeaderTypeCRC
+}
+
 func HeaderTypeFromString(value string) HeaderTypeEnum {
 	switch strings.ToLower(value) {
 	case "cpio":
@@ -68,6 +83,10 @@ func HeaderTypeFromString(value string) HeaderTypeEnum {
 		return HeaderTypeXZ
 	case "gz":
 		return HeaderTypeGZ
+	case "odc":
+		return HeaderTypeODC
+	case "crc":
+		return HeaderTypeCRC
 	default:
 		return HeaderTypeUnknown
 	}
@@ -83,6 +102,14 @@ func HeaderTypeFromReader(r io.Reader) (HeaderTypeEnum, error) {
 		return HeaderTypeCPIO, nil
 	}
 
+	if bytes.Equal(buff, odcMagic) {
+		return HeaderTypeODC, nil
+	}
+
+	if bytes.Equal(buff, crcMagic) {
+		return HeaderTypeCRC, nil
+	}
+
 	if bytes.Equal(buff, xzMagic) {
 		return HeaderTypeXZ, nil
 	}
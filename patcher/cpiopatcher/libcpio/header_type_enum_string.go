@@ -12,11 +12,13 @@ func _() {
 	_ = x[HeaderTypeCPIO-1]
 	_ = x[HeaderTypeXZ-2]
 	_ = x[HeaderTypeGZ-3]
+	_ = x[HeaderTypeODC-4]
+	_ = x[HeaderTypeCRC-5]
 }
 
-const _HeaderTypeEnum_name = "unknowncpioxzgz"
+const _HeaderTypeEnum_name = "unknowncpioxzgzodccrc"
 
-var _HeaderTypeEnum_index = [...]uint8{0, 7, 11, 13, 15}
+var _HeaderTypeEnum_index = [...]uint8{0, 7, 11, 13, 15, 18, 21}
 
 func (i HeaderTypeEnum) String() string {
 	idx := int(i) - 0
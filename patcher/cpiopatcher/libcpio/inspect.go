@@ -0,0 +1,323 @@
+package libcpio
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/liberrors"
+	"github.com/grinderz/go-libs/libio"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"github.com/xi2/xz"
+	"go.uber.org/zap"
+)
+
+const inspectBufferSize = 8192
+
+// Segment describes a single part of an image: a plain cpio archive or a compressed blob.
+// Offset and Size are positions in the image, Members are read from the (decompressed) cpio data.
+type Segment struct {
+	Type        HeaderTypeEnum
+	Offset      int64
+	Size        int64
+	ZeroPadding int64
+	Trailer     bool
+	Members     []Entry
+}
+
+type Layout struct {
+	Size     int64
+	Segments []Segment
+}
+
+// WalkFunc is called for every member of a segment, data reads the member contents
+// and is only valid until the function returns.
+type WalkFunc func(segment *Segment, entry *Entry, data io.Reader) error
+
+// Inspect walks the image and reports its segment layout with the members of each segment.
+// Compressed gz segments are measured exactly, xz segments are assumed to extend to the end of the image.
+// maxDecompressBytes caps the decompressed size of every segment, 0 disables the limit.
+func Inspect(reader io.ReadSeeker, maxDecompressBytes int64) (*Layout, error) {
+	return Walk(reader, maxDecompressBytes, nil)
+}
+
+// Walk is Inspect with fn called for every member, fn may be nil.
+func Walk(reader io.ReadSeeker, maxDecompressBytes int64, fn WalkFunc) (*Layout, error) {
+	size, err := reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("seek end: %w", err)
+	}
+
+	layout := &Layout{
+		Size:     size,
+		Segments: nil,
+	}
+
+	for offset := int64(0); offset < size; {
+		segment, err := inspectSegment(reader, offset, size, maxDecompressBytes, fn)
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("inspect segment: %w", err),
+				zap.Int64("offset", offset),
+			)
+		}
+
+		offset += segment.Size
+
+		if segment.ZeroPadding, err = countZeros(reader, offset, inspectBufferSize); err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("count zero padding: %w", err),
+				zap.Int64("offset", offset),
+			)
+		}
+
+		offset += segment.ZeroPadding
+
+		layout.Segments = append(layout.Segments, *segment)
+	}
+
+	return layout, nil
+}
+
+func inspectSegment(reader io.ReadSeeker, offset, size, maxDecompressBytes int64, fn WalkFunc) (*Segment, error) {
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek segment: %w", err)
+	}
+
+	segmentType, err := HeaderTypeFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("recognize header type: %w", err)
+	}
+
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek segment: %w", err)
+	}
+
+	segment := &Segment{
+		Type:        segmentType,
+		Offset:      offset,
+		Size:        0,
+		ZeroPadding: 0,
+		Trailer:     false,
+		Members:     nil,
+	}
+
+	counter := &countingReader{reader: bufio.NewReader(reader), count: 0}
+
+	switch segmentType {
+	case HeaderTypeCPIO, HeaderTypeODC, HeaderTypeCRC:
+		if segment.Members, segment.Size, segment.Trailer, err = readArchive(NewReader(counter), 0, segment, fn); err != nil {
+			return nil, fmt.Errorf("read cpio: %w", err)
+		}
+	case HeaderTypeGZ:
+		gzReader, err := gzip.NewReader(counter)
+		if err != nil {
+			return nil, fmt.Errorf("gz reader: %w", err)
+		}
+
+		gzReader.Multistream(false)
+
+		if segment.Members, segment.Trailer, err = readArchives(gzReader, maxDecompressBytes, segment, fn); err != nil {
+			return nil, fmt.Errorf("read gz cpio: %w", err)
+		}
+
+		segment.Size = counter.count
+	case HeaderTypeXZ:
+		xzReader, err := xz.NewReader(counter, 0)
+		if err != nil {
+			return nil, fmt.Errorf("xz reader: %w", err)
+		}
+
+		if segment.Members, segment.Trailer, err = readArchives(xzReader, maxDecompressBytes, segment, fn); err != nil {
+			return nil, fmt.Errorf("read xz cpio: %w", err)
+		}
+
+		segment.Size = size - offset
+	case HeaderTypeUnknown:
+		return nil, liberrors.NewInvalidStringEntityError("cpio_header_type", segmentType.String())
+	}
+
+	return segment, nil
+}
+
+// readArchives reads all concatenated cpio archives of a decompressed stream and drains it,
+// reading stops after an archive without a trailer.
+func readArchives(reader io.Reader, maxDecompressBytes int64, segment *Segment, fn WalkFunc) ([]Entry, bool, error) {
+	limited := libio.NewLimitReader(reader, nil, &libio.UnpackConfig{
+		MaxDecompressBytes: maxDecompressBytes,
+		MaxRatio:           0,
+	})
+
+	counter := &countingReader{
+		reader: bufio.NewReader(limited),
+		count:  0,
+	}
+
+	var (
+		entries []Entry
+		trailer bool
+	)
+
+	for {
+		if err := skipZeros(counter); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, trailer, nil
+			}
+
+			return nil, false, fmt.Errorf("skip archive padding: %w", err)
+		}
+
+		base := counter.count
+
+		archive, _, archiveTrailer, err := readArchive(NewReader(counter), base, segment, fn)
+		if err != nil {
+			return nil, false, zerr.Wrap(
+				fmt.Errorf("read archive: %w", err),
+				zap.Int64("archive_offset", base),
+			)
+		}
+
+		entries = append(entries, archive...)
+		trailer = archiveTrailer
+
+		if !trailer {
+			if _, err := io.Copy(io.Discard, counter); err != nil {
+				return nil, false, fmt.Errorf("drain stream: %w", err)
+			}
+
+			return entries, trailer, nil
+		}
+	}
+}
+
+// readArchive reads the members up to the trailer and returns them with the archive end position.
+// An archive without a trailer ends after the data of its last member, when the next header
+// can not be read because of EOF or a foreign magic.
+func readArchive(rdr *Reader, base int64, segment *Segment, fn WalkFunc) ([]Entry, int64, bool, error) {
+	var (
+		entries []Entry
+		end     int64
+	)
+
+	for {
+		entry, err := rdr.Next()
+		if err != nil {
+			if len(entries) > 0 && isArchiveEnd(err) {
+				return entries, end, false, nil
+			}
+
+			return nil, 0, false, zerr.Wrap(
+				fmt.Errorf("next entry: %w", err),
+				zap.Int("entries_read", len(entries)),
+			)
+		}
+
+		if entry.IsTrailer() {
+			return entries, rdr.Pos(), true, nil
+		}
+
+		entry.Offset += base
+
+		if fn != nil {
+			if err := fn(segment, entry, rdr); err != nil {
+				return nil, 0, false, zerr.Wrap(
+					fmt.Errorf("walk func: %w", err),
+					zap.String("entry_name", entry.Name),
+				)
+			}
+		}
+
+		if _, err := io.Copy(io.Discard, rdr); err != nil {
+			return nil, 0, false, zerr.Wrap(
+				fmt.Errorf("skip data: %w", err),
+				zap.String("entry_name", entry.Name),
+			)
+		}
+
+		end = rdr.Pos()
+		entries = append(entries, *entry)
+	}
+}
+
+func isArchiveEnd(err error) bool {
+	return errors.Is(err, ErrInvalidMagic) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func skipZeros(reader io.ByteScanner) error {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if b != zeroByte {
+			return reader.UnreadByte() //nolint:wrapcheck
+		}
+	}
+}
+
+// countZeros returns the number of zero bytes starting at offset, zeros up to EOF are counted as well.
+func countZeros(reader io.ReadSeeker, offset int64, bufferSize int) (int64, error) {
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek: %w", err)
+	}
+
+	counter := &countingReader{reader: bufio.NewReaderSize(reader, bufferSize), count: 0}
+
+	err := skipZeros(counter)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("skip zeros: %w", err)
+	}
+
+	return counter.count, nil
+}
+
+// countingReader counts consumed bytes, it implements io.ByteReader so that
+// decompressors do not read ahead of the end of their stream.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(buff []byte) (int, error) {
+	n, err := r.reader.Read(buff)
+	r.count += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+func (r *countingReader) ReadByte() (byte, error) {
+	if byteReader, ok := r.reader.(io.ByteReader); ok {
+		b, err := byteReader.ReadByte()
+		if err == nil {
+			r.count++
+		}
+
+		return b, err //nolint:wrapcheck
+	}
+
+	var buff [1]byte
+
+	if _, err := io.ReadFull(r, buff[:]); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return buff[0], nil
+}
+
+func (r *countingReader) UnreadByte() error {
+	scanner, ok := r.reader.(io.ByteScanner)
+	if !ok {
+		return liberrors.NewCastError("reader", r.reader, "io.ByteScanner")
+	}
+
+	if err := scanner.UnreadByte(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	r.count--
+
+	return nil
+}
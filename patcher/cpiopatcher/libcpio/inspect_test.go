@@ -0,0 +1,119 @@
+package libcpio_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+	cpio "github.com/grinderz/gocpio"
+)
+
+const (
+	testMaxDecompressBytes = 1 << 20
+	testFooterSize         = 512
+)
+
+type testFile struct {
+	name string
+	data string
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	head := buildCpio(t, []testFile{{"kernel/x86/microcode/GenuineIntel.bin", "ucode"}})
+	body := buildCpio(t, []testFile{{"init", "#!/bin/sh\n"}, {"etc/hostname", "host\n"}})
+
+	var image bytes.Buffer
+
+	image.Write(head)
+	image.Write(make([]byte, testFooterSize))
+	image.Write(gzipBytes(t, body))
+
+	layout, err := libcpio.Inspect(bytes.NewReader(image.Bytes()), testMaxDecompressBytes)
+	checkError(t, err)
+
+	if layout.Size != int64(image.Len()) {
+		t.Fatalf("layout size: %d != %d", layout.Size, image.Len())
+	}
+
+	if len(layout.Segments) != 2 {
+		t.Fatalf("segments count: %d != 2", len(layout.Segments))
+	}
+
+	cpioSegment, gzSegment := layout.Segments[0], layout.Segments[1]
+
+	if cpioSegment.Type != libcpio.HeaderTypeCPIO || gzSegment.Type != libcpio.HeaderTypeGZ {
+		t.Fatalf("segment types: %s, %s", cpioSegment.Type, gzSegment.Type)
+	}
+
+	if cpioSegment.ZeroPadding < testFooterSize {
+		t.Fatalf("cpio zero padding: %d < %d", cpioSegment.ZeroPadding, testFooterSize)
+	}
+
+	if gzSegment.Offset != cpioSegment.Size+cpioSegment.ZeroPadding {
+		t.Fatalf("gz offset: %d != %d", gzSegment.Offset, cpioSegment.Size+cpioSegment.ZeroPadding)
+	}
+
+	if gzSegment.Offset+gzSegment.Size+gzSegment.ZeroPadding != layout.Size {
+		t.Fatalf("gz segment does not end the image: %+v", gzSegment)
+	}
+
+	if len(gzSegment.Members) != 2 || gzSegment.Members[1].Name != "etc/hostname" {
+		t.Fatalf("gz members: %+v", gzSegment.Members)
+	}
+
+	member := gzSegment.Members[1]
+	if member.Size != int64(len("host\n")) || member.Perm() != 0o644 || member.FileType() != 0o100000 {
+		t.Fatalf("member metadata: %+v", member)
+	}
+}
+
+func buildCpio(t *testing.T, files []testFile) []byte {
+	t.Helper()
+
+	var buff bytes.Buffer
+
+	writer := cpio.NewWriter(&buff)
+
+	for _, file := range files {
+		checkError(t, writer.WriteHeader(&cpio.Header{
+			Name: file.name,
+			Mode: 0o644,
+			Type: cpio.TYPE_REG,
+			Size: int64(len(file.data)),
+		}))
+
+		if _, err := writer.Write([]byte(file.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkError(t, writer.Close())
+
+	return buff.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buff bytes.Buffer
+
+	writer := gzip.NewWriter(&buff)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	checkError(t, writer.Close())
+
+	return buff.Bytes()
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
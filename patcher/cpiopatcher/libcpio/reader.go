@@ -0,0 +1,280 @@
+package libcpio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	TrailerName = "TRAILER!!!"
+
+	newcHeaderSize   = 110
+	newcFieldSize    = 8
+	newcAlign        = 4
+	odcHeaderSize    = 76
+	odcAlign         = 1
+	odcDevShift      = 8
+	odcDevMinorMask  = 0xff
+	modeFileTypeMask = 0o170000
+	modePermMask     = 0o7777
+)
+
+// odcFieldSizes are the octal field widths following the magic:
+// dev, ino, mode, uid, gid, nlink, rdev, mtime, namesize, filesize.
+var odcFieldSizes = []int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11} //nolint:gochecknoglobals
+
+const (
+	ModeSocket  = 0o140000
+	ModeSymlink = 0o120000
+	ModeRegular = 0o100000
+	ModeBlock   = 0o060000
+	ModeDir     = 0o040000
+	ModeChar    = 0o020000
+	ModeFIFO    = 0o010000
+)
+
+// Entry describes a single cpio member header.
+type Entry struct {
+	Name      string
+	Mode      int64
+	UID       int
+	GID       int
+	Nlink     int
+	Mtime     int64
+	Size      int64
+	Inode     int64
+	DevMajor  int64
+	DevMinor  int64
+	RDevMajor int64
+	RDevMinor int64
+	Check     uint32
+	Offset    int64
+	Format    HeaderTypeEnum
+}
+
+func (e *Entry) FileType() int64 {
+	return e.Mode & modeFileTypeMask
+}
+
+func (e *Entry) Perm() int64 {
+	return e.Mode & modePermMask
+}
+
+func (e *Entry) ModTime() time.Time {
+	return time.Unix(e.Mtime, 0).UTC()
+}
+
+func (e *Entry) IsTrailer() bool {
+	return e.Name == TrailerName
+}
+
+// Reader reads newc, crc and odc cpio members one by one, keeping track of the stream position.
+type Reader struct {
+	reader    io.Reader
+	pos       int64
+	remaining int64
+	align     int64
+	sum       uint32
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		reader: r,
+		align:  newcAlign,
+	}
+}
+
+// Next skips the rest of the current member and returns the next header.
+// The trailer entry is returned as is, callers check Entry.IsTrailer.
+func (r *Reader) Next() (*Entry, error) {
+	if err := r.skip(r.remaining); err != nil {
+		return nil, fmt.Errorf("skip data: %w", err)
+	}
+
+	r.remaining = 0
+
+	if err := r.skip(padding(r.pos, r.align)); err != nil {
+		return nil, fmt.Errorf("skip data padding: %w", err)
+	}
+
+	offset := r.pos
+
+	magic := make([]byte, MaxMagicSize)
+	if err := r.readFull(magic); err != nil {
+		return nil, fmt.Errorf("read magic: %w", err)
+	}
+
+	entry, nameSize, err := r.readHeader(magic)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Offset = offset
+
+	name := make([]byte, nameSize)
+	if err := r.readFull(name); err != nil {
+		return nil, fmt.Errorf("read name: %w", err)
+	}
+
+	entry.Name = string(bytes.TrimRight(name, "\x00"))
+
+	if err := r.skip(padding(r.pos, r.align)); err != nil {
+		return nil, fmt.Errorf("skip name padding: %w", err)
+	}
+
+	r.sum = 0
+	r.remaining = entry.Size
+
+	return entry, nil
+}
+
+// Read reads the data of the current member.
+func (r *Reader) Read(buff []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(buff)) > r.remaining {
+		buff = buff[:r.remaining]
+	}
+
+	n, err := r.reader.Read(buff)
+	r.pos += int64(n)
+	r.remaining -= int64(n)
+	r.sum += Checksum(buff[:n])
+
+	if err == io.EOF && r.remaining > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+// Sum returns the crc format checksum of the current member data read so far.
+func (r *Reader) Sum() uint32 {
+	return r.sum
+}
+
+// Pos returns the number of bytes consumed from the underlying reader.
+func (r *Reader) Pos() int64 {
+	return r.pos
+}
+
+func (r *Reader) readFull(buff []byte) error {
+	n, err := io.ReadFull(r.reader, buff)
+	r.pos += int64(n)
+
+	return err //nolint:wrapcheck
+}
+
+func (r *Reader) skip(size int64) error {
+	if size == 0 {
+		return nil
+	}
+
+	n, err := io.CopyN(io.Discard, r.reader, size)
+	r.pos += n
+
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err //nolint:wrapcheck
+}
+
+func padding(pos, align int64) int64 {
+	return (align - pos%align) % align
+}
+
+func (r *Reader) readHeader(magic []byte) (*Entry, int64, error) {
+	var (
+		raw    []byte
+		parse  func(raw []byte) (*Entry, int64, error)
+		format HeaderTypeEnum
+	)
+
+	switch {
+	case bytes.Equal(magic, cpioMagic):
+		raw, parse, format, r.align = make([]byte, newcHeaderSize-MaxMagicSize), parseNewcHeader, HeaderTypeCPIO, newcAlign
+	case bytes.Equal(magic, crcMagic):
+		raw, parse, format, r.align = make([]byte, newcHeaderSize-MaxMagicSize), parseNewcHeader, HeaderTypeCRC, newcAlign
+	case bytes.Equal(magic, odcMagic):
+		raw, parse, format, r.align = make([]byte, odcHeaderSize-MaxMagicSize), parseODCHeader, HeaderTypeODC, odcAlign
+	default:
+		return nil, 0, fmt.Errorf("%w: %q", ErrInvalidMagic, magic)
+	}
+
+	if err := r.readFull(raw); err != nil {
+		return nil, 0, fmt.Errorf("read header: %w", err)
+	}
+
+	entry, nameSize, err := parse(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entry.Format = format
+
+	return entry, nameSize, nil
+}
+
+func parseNewcHeader(raw []byte) (*Entry, int64, error) {
+	fields := make([]int64, 0, len(raw)/newcFieldSize)
+
+	for pos := 0; pos < len(raw); pos += newcFieldSize {
+		value, err := strconv.ParseUint(string(raw[pos:pos+newcFieldSize]), 16, 32)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse newc header field at %d: %w", pos+MaxMagicSize, err)
+		}
+
+		fields = append(fields, int64(value))
+	}
+
+	return &Entry{
+		Inode:     fields[0],
+		Mode:      fields[1],
+		UID:       int(fields[2]),
+		GID:       int(fields[3]),
+		Nlink:     int(fields[4]),
+		Mtime:     fields[5],
+		Size:      fields[6],
+		DevMajor:  fields[7],
+		DevMinor:  fields[8],
+		RDevMajor: fields[9],
+		RDevMinor: fields[10],
+		Check:     uint32(fields[12]), //nolint:gosec
+	}, fields[11], nil
+}
+
+func parseODCHeader(raw []byte) (*Entry, int64, error) {
+	fields := make([]int64, 0, len(odcFieldSizes))
+	pos := 0
+
+	for _, size := range odcFieldSizes {
+		value, err := strconv.ParseUint(string(raw[pos:pos+size]), 8, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse odc header field at %d: %w", pos+MaxMagicSize, err)
+		}
+
+		fields = append(fields, int64(value)) //nolint:gosec
+		pos += size
+	}
+
+	return &Entry{
+		DevMajor:  fields[0] >> odcDevShift,
+		DevMinor:  fields[0] & odcDevMinorMask,
+		Inode:     fields[1],
+		Mode:      fields[2],
+		UID:       int(fields[3]),
+		GID:       int(fields[4]),
+		Nlink:     int(fields[5]),
+		RDevMajor: fields[6] >> odcDevShift,
+		RDevMinor: fields[6] & odcDevMinorMask,
+		Mtime:     fields[7],
+		Size:      fields[9],
+	}, fields[8], nil
+}
@@ -0,0 +1,136 @@
+package libcpio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/grinderz/go-libs/liberrors"
+	"github.com/grinderz/go-libs/libzap/zerr"
+	"go.uber.org/zap"
+)
+
+const (
+	specCommonFields = 5
+	specNodFields    = 8
+)
+
+// ParseSpec reads nodes from a gen_init_cpio style spec, supported lines are:
+//
+//	dir <name> <mode> <uid> <gid>
+//	nod <name> <mode> <uid> <gid> <b|c> <maj> <min>
+//	pipe <name> <mode> <uid> <gid>
+//	sock <name> <mode> <uid> <gid>
+//
+// Empty lines and lines starting with # are skipped.
+func ParseSpec(reader io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(reader)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseSpecLine(strings.Fields(line))
+		if err != nil {
+			return nil, zerr.Wrap(
+				fmt.Errorf("parse spec line: %w", err),
+				zap.Int("line_num", lineNum),
+				zap.String("line", line),
+			)
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan spec: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseSpecLine(fields []string) (*Entry, error) {
+	var fileType int64
+
+	expectedFields := specCommonFields
+
+	switch fields[0] {
+	case "dir":
+		fileType = ModeDir
+	case "nod":
+		expectedFields = specNodFields
+	case "pipe":
+		fileType = ModeFIFO
+	case "sock":
+		fileType = ModeSocket
+	default:
+		return nil, liberrors.NewInvalidStringEntityError("cpio_spec_type", fields[0])
+	}
+
+	if len(fields) != expectedFields {
+		return nil, liberrors.NewInvalidIntEntityError("cpio_spec_fields_count", len(fields))
+	}
+
+	values, err := parseSpecNumbers(fields[2:specCommonFields], []int{8, 10, 10})
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		Name:  strings.TrimLeft(fields[1], "/"),
+		Mode:  values[0] & modePermMask,
+		UID:   int(values[1]),
+		GID:   int(values[2]),
+		Nlink: 1,
+	}
+
+	if fileType == ModeDir {
+		entry.Nlink = 2
+	}
+
+	if fields[0] == "nod" {
+		switch fields[5] {
+		case "b":
+			fileType = ModeBlock
+		case "c":
+			fileType = ModeChar
+		default:
+			return nil, liberrors.NewInvalidStringEntityError("cpio_spec_dev_type", fields[5])
+		}
+
+		dev, err := parseSpecNumbers(fields[6:specNodFields], []int{10, 10})
+		if err != nil {
+			return nil, err
+		}
+
+		entry.RDevMajor, entry.RDevMinor = dev[0], dev[1]
+	}
+
+	entry.Mode |= fileType
+
+	return entry, nil
+}
+
+func parseSpecNumbers(fields []string, bases []int) ([]int64, error) {
+	values := make([]int64, len(fields))
+
+	for ind, field := range fields {
+		value, err := strconv.ParseInt(field, bases[ind], 64)
+		if err != nil {
+			return nil, zerr.Wrap(
+				liberrors.NewInvalidStringEntityError("cpio_spec_number", field),
+				zap.Error(err),
+			)
+		}
+
+		values[ind] = value
+	}
+
+	return values, nil
+}
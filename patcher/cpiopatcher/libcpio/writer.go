@@ -0,0 +1,198 @@
+package libcpio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/grinderz/go-libs/liberrors"
+)
+
+const (
+	trailerAlign   = 512
+	newcHeaderMask = "%s%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X"
+	odcHeaderMask  = "%s%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o"
+)
+
+// Writer writes cpio members, unlike gocpio it keeps inode, nlink and rdev from the entry.
+// In crc format Entry.Check must hold the Checksum of the member data, it is verified on the next header.
+type Writer struct {
+	writer    io.Writer
+	format    HeaderTypeEnum
+	pos       int64
+	remaining int64
+	entry     Entry
+	sum       uint32
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return NewWriterWithFormat(w, HeaderTypeCPIO)
+}
+
+// NewWriterWithFormat creates a writer of the given cpio format (cpio, crc, odc).
+func NewWriterWithFormat(w io.Writer, format HeaderTypeEnum) *Writer {
+	return &Writer{
+		writer: w,
+		format: format,
+	}
+}
+
+// WriteHeader finishes the current member and writes the header of the next one.
+func (w *Writer) WriteHeader(entry *Entry) error {
+	if err := w.finish(); err != nil {
+		return err
+	}
+
+	name := append([]byte(entry.Name), zeroByte)
+
+	header, err := w.header(entry, len(name))
+	if err != nil {
+		return err
+	}
+
+	if err := w.write([]byte(header)); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	if err := w.write(name); err != nil {
+		return fmt.Errorf("write name: %w", err)
+	}
+
+	if err := w.pad(w.align()); err != nil {
+		return fmt.Errorf("write name padding: %w", err)
+	}
+
+	w.remaining = entry.Size
+	w.entry = *entry
+	w.sum = 0
+
+	return nil
+}
+
+// Write writes the data of the current member, at most Entry.Size bytes.
+func (w *Writer) Write(buff []byte) (int, error) {
+	if int64(len(buff)) > w.remaining {
+		return 0, ErrWriteTooLong
+	}
+
+	n, err := w.writer.Write(buff)
+	w.pos += int64(n)
+	w.remaining -= int64(n)
+	w.sum += Checksum(buff[:n])
+
+	return n, err //nolint:wrapcheck
+}
+
+// Close writes the trailer and pads the archive to 512 bytes, the underlying writer is not closed.
+func (w *Writer) Close() error {
+	if err := w.WriteHeader(&Entry{Name: TrailerName, Nlink: 1}); err != nil {
+		return fmt.Errorf("write trailer: %w", err)
+	}
+
+	if err := w.pad(trailerAlign); err != nil {
+		return fmt.Errorf("write trailer padding: %w", err)
+	}
+
+	return nil
+}
+
+// Pos returns the number of bytes written to the underlying writer.
+func (w *Writer) Pos() int64 {
+	return w.pos
+}
+
+func (w *Writer) finish() error {
+	if w.remaining > 0 {
+		if err := w.write(make([]byte, w.remaining)); err != nil {
+			return fmt.Errorf("write data remaining: %w", err)
+		}
+
+		w.remaining = 0
+	}
+
+	if w.format == HeaderTypeCRC && w.sum != w.entry.Check {
+		return newChecksumMismatchError(w.entry.Name, w.entry.Check, w.sum)
+	}
+
+	if err := w.pad(w.align()); err != nil {
+		return fmt.Errorf("write data padding: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Writer) header(entry *Entry, nameSize int) (string, error) {
+	switch w.format {
+	case HeaderTypeCPIO, HeaderTypeCRC:
+		magic, check := cpioMagic, uint32(0)
+		if w.format == HeaderTypeCRC {
+			magic, check = crcMagic, entry.Check
+		}
+
+		return fmt.Sprintf(
+			newcHeaderMask,
+			magic,
+			entry.Inode,
+			entry.Mode,
+			entry.UID,
+			entry.GID,
+			entry.Nlink,
+			entry.Mtime,
+			entry.Size,
+			entry.DevMajor,
+			entry.DevMinor,
+			entry.RDevMajor,
+			entry.RDevMinor,
+			nameSize,
+			check,
+		), nil
+	case HeaderTypeODC:
+		fields := []int64{
+			entry.DevMajor<<odcDevShift | entry.DevMinor,
+			entry.Inode,
+			entry.Mode,
+			int64(entry.UID),
+			int64(entry.GID),
+			int64(entry.Nlink),
+			entry.RDevMajor<<odcDevShift | entry.RDevMinor,
+			entry.Mtime,
+			int64(nameSize),
+			entry.Size,
+		}
+
+		values := make([]any, 0, len(fields)+1)
+		values = append(values, odcMagic)
+
+		for ind, field := range fields {
+			if field < 0 || field >= 1<<(3*odcFieldSizes[ind]) {
+				return "", newODCFieldOverflowError(entry.Name, ind, field)
+			}
+
+			values = append(values, field)
+		}
+
+		return fmt.Sprintf(odcHeaderMask, values...), nil
+	case HeaderTypeGZ, HeaderTypeXZ, HeaderTypeUnknown:
+		fallthrough
+	default:
+		return "", liberrors.NewInvalidStringEntityError("cpio_format", w.format.String())
+	}
+}
+
+func (w *Writer) align() int64 {
+	if w.format == HeaderTypeODC {
+		return odcAlign
+	}
+
+	return newcAlign
+}
+
+func (w *Writer) pad(align int64) error {
+	return w.write(make([]byte, padding(w.pos, align)))
+}
+
+func (w *Writer) write(buff []byte) error {
+	n, err := w.writer.Write(buff)
+	w.pos += int64(n)
+
+	return err //nolint:wrapcheck
+}
@@ -4,8 +4,8 @@
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
-	"github.com/grinderz/go-libs/liberrors"
 	"github.com/grinderz/go-libs/libio"
 	"github.com/grinderz/go-libs/libzap"
 	"github.com/grinderz/go-libs/libzap/zerr"
@@ -18,25 +18,26 @@
 	bufferSize         = 8192
 	filePerm           = 0o644
 	maxDecompressBytes = 524_288_000
+	progressInterval   = 10 * time.Second
 )
 
 type Patcher struct {
-	tempDir            string
-	path               string
-	fileName           string
-	cpioZeroFooterSize int64
-	result             chan<- patcher.Result
-	logger             *zap.Logger
+	tempDir      string
+	path         string
+	fileName     string
+	headerLayout *libcpio.HeaderLayout
+	result       chan<- patcher.Result
+	logger       *zap.Logger
 }
 
 func New(temp, path string, result chan<- patcher.Result) *Patcher {
 	return &Patcher{
-		tempDir:            temp,
-		path:               path,
-		fileName:           filepath.Base(path),
-		cpioZeroFooterSize: 0,
-		result:             result,
-		logger:             libzap.Logger().With(libzap.FieldPkg("cpio_patcher")),
+		tempDir:      temp,
+		path:         path,
+		fileName:     filepath.Base(path),
+		headerLayout: nil,
+		result:       result,
+		logger:       libzap.Logger().With(libzap.FieldPkg("cpio_patcher")),
 	}
 }
 
@@ -67,7 +68,7 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 		return
 	}
 
-	if fileType == libcpio.HeaderTypeCPIO {
+	if fileType.IsCPIO() {
 		p.logger.Info(
 			p.path+": cut cpio header",
 			zap.String("path", p.path),
@@ -76,8 +77,7 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 
 		cpioFilePath := filepath.Join(p.tempDir, p.fileName+".cpio")
 
-		cpioFile, err := os.Create(cpioFilePath)
-		if err != nil {
+		if cpioFile, err = os.Create(cpioFilePath); err != nil {
 			p.result <- patcher.NewError(
 				p.path,
 				zerr.Wrap(
@@ -98,7 +98,7 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 			}
 		}()
 
-		if fileType, p.cpioZeroFooterSize, err = libcpio.CutHeader(inFile, cpioFile, bufferSize); err != nil {
+		if p.headerLayout, err = libcpio.CutHeaderLayout(inFile, cpioFile, bufferSize); err != nil {
 			p.result <- patcher.NewError(
 				p.path,
 				zerr.Wrap(
@@ -109,6 +109,17 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 
 			return
 		}
+
+		fileType = p.headerLayout.PayloadType
+
+		p.logger.Info(
+			fmt.Sprintf("%s: cut %d cpio segments", p.path, len(p.headerLayout.Segments)),
+			zap.String("path", p.path),
+			zap.Int("cpio_segments", len(p.headerLayout.Segments)),
+			zap.Int64("cpio_size", p.headerLayout.Size),
+			zap.Int64("cpio_zero_footer", p.headerLayout.ZeroFooter),
+			zap.Stringer("payload_type", fileType),
+		)
 	}
 
 	rawFilePath := filepath.Join(p.tempDir, p.fileName+".raw")
@@ -165,6 +176,19 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 		return
 	}
 
+	if err := p.fixChecksums(rawFile); err != nil {
+		p.result <- patcher.NewError(
+			p.path,
+			zerr.Wrap(
+				fmt.Errorf("fix checksums: %w", err),
+				zap.Stringer("file_type", fileType),
+				zap.String("raw_path", rawFilePath),
+			),
+		)
+
+		return
+	}
+
 	if err := p.pack(rawFile, inFile, cpioFile, backup); err != nil {
 		p.result <- patcher.NewError(
 			p.path,
@@ -181,20 +205,18 @@ func (p *Patcher) Patch(patterns []*patcher.Pattern, backup bool) {
 	p.result <- patcher.NewResult(p.path, replaced)
 }
 
-func (p *Patcher) backup(inFile *os.File) error {
+func (p *Patcher) backup() error {
+	method, err := libio.CloneFile(p.path, p.path+".bak")
+	if err != nil {
+		return fmt.Errorf("clone file: %w", err)
+	}
+
 	p.logger.Info(
-		p.path+": backup",
+		p.path+": backup "+method.String(),
 		zap.String("path", p.path),
+		zap.Stringer("clone_method", method),
 	)
 
-	if _, err := inFile.Seek(0, 0); err != nil {
-		return fmt.Errorf("file seek: %w", err)
-	}
-
-	if err := libio.CloneReader(inFile, p.path+".bak"); err != nil {
-		return fmt.Errorf("clone reader: %w", err)
-	}
-
 	return nil
 }
 
@@ -203,29 +225,24 @@ func (p *Patcher) unpack(rawFile, inFile *os.File, fileType libcpio.HeaderTypeEn
 		return fmt.Errorf("in file seek: %w", err)
 	}
 
-	switch fileType {
-	case libcpio.HeaderTypeXZ:
-		p.logger.Info(
-			p.path+": unpack xz",
-			zap.String("path", p.path),
-			zap.Stringer("file_type", fileType),
-		)
+	codec, err := libio.CodecByName(fileType.String())
+	if err != nil {
+		return fmt.Errorf("codec: %w", err)
+	}
 
-		if err := libio.UnpackXZ(rawFile, inFile); err != nil {
-			return fmt.Errorf("unpack xz: %w", err)
-		}
-	case libcpio.HeaderTypeGZ:
-		p.logger.Info(
-			p.path+": unpack gz",
-			zap.String("path", p.path),
-			zap.Stringer("file_type", fileType),
-		)
+	p.logger.Info(
+		p.path+": unpack "+codec.Name(),
+		zap.String("path", p.path),
+		zap.Stringer("file_type", fileType),
+	)
 
-		if err := libio.UnpackGZ(rawFile, inFile, maxDecompressBytes); err != nil {
-			return fmt.Errorf("unpack gz: %w", err)
-		}
-	case libcpio.HeaderTypeCPIO, libcpio.HeaderTypeUnknown:
-		return liberrors.NewInvalidStringEntityError("cpio_header_type", fileType.String())
+	limits := &libio.UnpackConfig{
+		MaxDecompressBytes: maxDecompressBytes,
+		MaxRatio:           0,
+	}
+
+	if err := libio.UnpackWithOptions(rawFile, inFile, codec, limits, p.progressOptions("unpack")); err != nil {
+		return fmt.Errorf("unpack %s: %w", codec.Name(), err)
 	}
 
 	return nil
@@ -295,9 +312,42 @@ func (p *Patcher) patch(rawFile *os.File, patterns []*patcher.Pattern) (int, err
 	return replaced, nil
 }
 
+// fixChecksums recomputes the checksums of the crc members of every archive in the patched image,
+// payloads that are not cpio archives have no checksums.
+func (p *Patcher) fixChecksums(rawFile *os.File) error {
+	if _, err := rawFile.Seek(0, 0); err != nil {
+		return fmt.Errorf("raw file seek: %w", err)
+	}
+
+	rawType, err := libcpio.HeaderTypeFromReader(rawFile)
+	if err != nil || !rawType.IsCPIO() {
+		return nil //nolint:nilerr
+	}
+
+	fixed, err := libcpio.RecomputeChecksums(rawFile)
+	if err != nil {
+		return fmt.Errorf("recompute checksums: %w", err)
+	}
+
+	if fixed == 0 {
+		return nil
+	}
+
+	p.logger.Info(
+		fmt.Sprintf("%s: fixed %d crc checksums", p.path, fixed),
+		zap.String("path", p.path),
+		zap.Int("fixed_checksums", fixed),
+	)
+
+	return nil
+}
+
+// pack writes the patched payload as gz whatever its original compression.
 func (p *Patcher) pack(rawFile, inFile, cpioFile *os.File, backup bool) error {
+	codec := libio.NewGZCodec(libio.NewGZPackConfig())
+
 	if backup {
-		if err := p.backup(inFile); err != nil {
+		if err := p.backup(); err != nil {
 			return fmt.Errorf("backup: %w", err)
 		}
 	}
@@ -319,19 +369,29 @@ func (p *Patcher) pack(rawFile, inFile, cpioFile *os.File, backup bool) error {
 			return fmt.Errorf("cpio file seek: %w", err)
 		}
 
-		if err := libcpio.WriteHeader(inFile, cpioFile, p.cpioZeroFooterSize); err != nil {
+		if err := libcpio.WriteHeaderLayout(inFile, cpioFile, p.headerLayout, libcpio.AlignExact); err != nil {
 			return fmt.Errorf("cpio header write: %w", err)
 		}
 	}
 
 	p.logger.Info(
-		p.path+": pack gz",
+		p.path+": pack "+codec.Name(),
 		zap.String("path", p.path),
 	)
 
-	if err := libio.PackGZ(inFile, rawFile); err != nil {
-		return fmt.Errorf("pack gz: %w", err)
+	if err := libio.PackWithOptions(inFile, rawFile, codec, libio.DefaultLevel, p.progressOptions("pack")); err != nil {
+		return fmt.Errorf("pack %s: %w", codec.Name(), err)
 	}
 
 	return nil
 }
+
+func (p *Patcher) progressOptions(operation string) *libio.Options {
+	return &libio.Options{
+		Progress:         libio.NewProgressLogger(p.logger, p.path+": "+operation+" progress"),
+		ProgressInterval: progressInterval,
+		Total:            0,
+		RateLimiter:      nil,
+		Hashes:           nil,
+	}
+}
@@ -0,0 +1,135 @@
+package cpiopatcher_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grinderz/go-libs/libio"
+	"github.com/grinderz/go-libs/patcher"
+	"github.com/grinderz/go-libs/patcher/cpiopatcher"
+	"github.com/grinderz/go-libs/patcher/cpiopatcher/libcpio"
+)
+
+func TestPatchGZ(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "lib"), []byte("library"), 0o644))
+
+	for name, test := range map[string]struct {
+		format      libcpio.HeaderTypeEnum
+		trailerless bool
+	}{
+		"newc trailerless": {libcpio.HeaderTypeCPIO, true},
+		"crc":              {libcpio.HeaderTypeCRC, false},
+		"crc trailerless":  {libcpio.HeaderTypeCRC, true},
+	} {
+		var archive bytes.Buffer
+
+		checkError(t, libcpio.BuildArchive(&archive, root, &libcpio.BuildConfig{
+			UID:         0,
+			GID:         0,
+			Mtime:       0,
+			DeviceSpec:  "",
+			Format:      test.format,
+			Compression: libcpio.HeaderTypeCPIO,
+		}))
+
+		raw := archive.Bytes()
+		if test.trailerless {
+			// cut the trailer header, 110 bytes before its name
+			raw = raw[:bytes.Index(raw, []byte(libcpio.TrailerName))-110]
+		}
+
+		var packed bytes.Buffer
+
+		checkError(t, libio.PackGZ(&packed, bytes.NewReader(raw)))
+
+		path := filepath.Join(t.TempDir(), "initrd.img")
+		checkError(t, os.WriteFile(path, packed.Bytes(), 0o600))
+
+		result := make(chan patcher.Result, 1)
+		cpiopatcher.New(t.TempDir(), path, result).Patch([]*patcher.Pattern{
+			{Description: "library", Count: 1, Search: []byte("library"), Replace: []byte("LIBRARY")},
+		}, false)
+
+		if res := <-result; res.Err != nil || res.BytesPatched != len("LIBRARY") {
+			t.Fatalf("%s result: %+v", name, res)
+		}
+
+		file, err := os.Open(path)
+		checkError(t, err)
+
+		var unpacked bytes.Buffer
+
+		checkError(t, libio.UnpackGZ(&unpacked, file, 1<<20))
+		checkError(t, file.Close())
+
+		// crc members also get a new check field
+		expected := bytes.Replace(raw, []byte("library"), []byte("LIBRARY"), 1)
+		if test.format != libcpio.HeaderTypeCRC && !bytes.Equal(unpacked.Bytes(), expected) {
+			t.Fatalf("%s unpacked: %q", name, unpacked.Bytes())
+		}
+
+		layout, err := libcpio.Inspect(bytes.NewReader(unpacked.Bytes()), 1<<20)
+		checkError(t, err)
+
+		if member := layout.Segments[0].Members[0]; member.Format == libcpio.HeaderTypeCRC &&
+			member.Check != libcpio.Checksum([]byte("LIBRARY")) {
+			t.Fatalf("%s check: %08X", name, member.Check)
+		}
+	}
+}
+
+func TestPatchXZRepacksGZ(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	checkError(t, os.WriteFile(filepath.Join(root, "lib"), []byte("library"), 0o644))
+
+	var archive, packed bytes.Buffer
+
+	checkError(t, libcpio.BuildArchive(&archive, root, &libcpio.BuildConfig{
+		UID:         0,
+		GID:         0,
+		Mtime:       0,
+		DeviceSpec:  "",
+		Format:      libcpio.HeaderTypeCPIO,
+		Compression: libcpio.HeaderTypeCPIO,
+	}))
+	checkError(t, libio.PackXZ(&packed, bytes.NewReader(archive.Bytes()), libio.NewXZKernelConfig()))
+
+	path := filepath.Join(t.TempDir(), "initrd.img")
+	checkError(t, os.WriteFile(path, packed.Bytes(), 0o600))
+
+	result := make(chan patcher.Result, 1)
+	cpiopatcher.New(t.TempDir(), path, result).Patch([]*patcher.Pattern{
+		{Description: "library", Count: 1, Search: []byte("library"), Replace: []byte("LIBRARY")},
+	}, false)
+
+	if res := <-result; res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	file, err := os.Open(path)
+	checkError(t, err)
+
+	defer file.Close()
+
+	fileType, err := libcpio.HeaderTypeFromReader(file)
+	checkError(t, err)
+
+	if fileType != libcpio.HeaderTypeGZ {
+		t.Fatalf("repacked type: %s", fileType)
+	}
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -72,7 +72,8 @@ func SearchBytes(f io.Reader, find []byte, buffSize int, resultCap int) ([]int64
 			return nil, fmt.Errorf("read buffer: %w", err)
 		}
 
-		for ind, b := range buff {
+		// only the bytes of this read, the rest of the buffer is left over from the previous one
+		for ind, b := range buff[:readCounter] {
 			if b != find[matchIndex] {
 				matchIndex = 0
 				continue